@@ -0,0 +1,51 @@
+// Command pwnd-ranges splits a downloaded Pwned Passwords file ordered by hash into
+// a directory of range files, which spass can use through HAVEIBEENPWND_DATABASE.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/romeovs/spass/pkg/pwnd"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	app := &cli.App{
+		Name:      "pwnd-ranges",
+		ArgsUsage: "[file] [directory]",
+		Usage:     "split a Pwned Passwords file ordered by hash into range files, use - to read from stdin",
+		Action: func(cli *cli.Context) error {
+			filename := cli.Args().Get(0)
+			dir := cli.Args().Get(1)
+			if filename == "" || dir == "" {
+				return errors.New("no file or directory provided")
+			}
+
+			var dump io.Reader = os.Stdin
+			if filename != "-" {
+				file, err := os.Open(filename)
+				if err != nil {
+					return fmt.Errorf("could not read '%s'", filename)
+				}
+				defer file.Close()
+				dump = file
+			}
+
+			count, err := pwnd.WriteRanges(dump, dir)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cli.App.Writer, "wrote %d range files to '%s'\n", count, dir)
+			return nil
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
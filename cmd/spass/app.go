@@ -0,0 +1,1577 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/pquerna/otp"
+	"github.com/romeovs/spass/pkg/clipboard"
+	"github.com/romeovs/spass/pkg/editor"
+	"github.com/romeovs/spass/pkg/generate"
+	"github.com/romeovs/spass/pkg/git"
+	"github.com/romeovs/spass/pkg/migration"
+	"github.com/romeovs/spass/pkg/prompt"
+	"github.com/romeovs/spass/pkg/pwnd"
+	"github.com/romeovs/spass/pkg/spass"
+	"github.com/romeovs/spass/pkg/strength"
+	"github.com/urfave/cli/v2"
+)
+
+// newApp creates the command line app, running the commands against the store.
+func newApp(ctx context.Context, env *spass.Env, store spass.Store) *cli.App {
+	// files gets the password store on disk, some commands do not make sense for other stores.
+	files := func() (*spass.FileStore, error) {
+		files, ok := store.(*spass.FileStore)
+		if !ok {
+			return nil, errors.New("this command only works for a password store on disk")
+		}
+		return files, nil
+	}
+
+	// copyToClipboard copies the text and clears the clipboard after PASSWORD_STORE_CLIP_TIME.
+	copyToClipboard := func(text string) error {
+		timeout, err := env.ClipTime()
+		if err != nil {
+			return err
+		}
+		return clipboard.Copy(text, timeout)
+	}
+
+	// commit commits the changed paths when the store is a git repository.
+	commit := func(message string, paths ...string) error {
+		if _, ok := store.(*spass.FileStore); !ok {
+			return nil
+		}
+
+		repo := git.Open(ctx, env.PASSWORD_STORE_DIR)
+		if repo == nil {
+			return nil
+		}
+		return repo.Commit(ctx, message, paths...)
+	}
+
+	// commitSecret commits the changes to the secret when it is stored in a git repository.
+	commitSecret := func(message string, secret spass.Secret) error {
+		file, ok := secret.(*spass.SecretFile)
+		if !ok {
+			return nil
+		}
+		return commit(message, file.Filename())
+	}
+
+	// history finds the git repository and the path of the secret in it.
+	// The secret does not need to exist anymore.
+	history := func(name string) (*git.Repo, *spass.SecretFile, string, error) {
+		repo := git.Open(ctx, env.PASSWORD_STORE_DIR)
+		if repo == nil {
+			return nil, nil, "", errors.New("the password store is not a git repository")
+		}
+
+		files, err := files()
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		secret, err := files.Secret(ctx, name)
+		if err != nil {
+			secret, err = files.NewSecret(ctx, name)
+			if err != nil {
+				return nil, nil, "", err
+			}
+		}
+
+		file := secret.(*spass.SecretFile)
+		path, err := filepath.Rel(env.PASSWORD_STORE_DIR, file.Filename())
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		return repo, file, path, nil
+	}
+
+	// revision decrypts the secret as it was in a git revision of the store.
+	revision := func(name string, rev string) (*spass.SecretFile, string, error) {
+		repo, secret, path, err := history(name)
+		if err != nil {
+			return nil, "", err
+		}
+
+		buf, err := repo.Show(ctx, rev, path)
+		if err != nil {
+			return nil, "", fmt.Errorf("secret '%s' not found in revision '%s'", name, rev)
+		}
+
+		body, err := secret.Decrypt(ctx, buf)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return secret, body, nil
+	}
+
+	// pwndChecker checks passwords against the local database in HAVEIBEENPWND_DATABASE,
+	// or the API at HAVEIBEENPWND_URL when there is none.
+	pwndChecker := func() (pwnd.Checker, error) {
+		if env.HAVEIBEENPWND_DATABASE != "" {
+			return pwnd.NewDatabase(env.HAVEIBEENPWND_DATABASE)
+		}
+		return pwnd.NewClientWithURL(env.HAVEIBEENPWND_API_KEY, env.HAVEIBEENPWND_URL, nil), nil
+	}
+
+	// policy reads the options to generate passwords with for the secret,
+	// from the .spass-policy file of its namespace.
+	policy := func(name string) (*generate.Options, string, error) {
+		options := generate.DefaultOptions()
+
+		files, ok := store.(*spass.FileStore)
+		if !ok {
+			return options, "", nil
+		}
+
+		filename := files.PolicyFile(name)
+		if filename == "" {
+			return options, "", nil
+		}
+
+		err := options.ReadFile(filename)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return options, filename, nil
+	}
+
+	return &cli.App{
+		Name:                   "spass",
+		Usage:                  "a fun password manager, compatible with pass.",
+		Suggest:                true,
+		UseShortOptionHandling: true,
+		Commands: []*cli.Command{
+			{
+				Name:  "env",
+				Usage: "print the relevant environment variables or defaults",
+				Action: func(cli *cli.Context) error {
+					env.Print(cli.App.Writer)
+					return nil
+				},
+			},
+			{
+				Name:      "init",
+				ArgsUsage: "[keyid...]",
+				Usage:     "set the gpg keys of the store or a namespace and re-encrypt its secrets",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "path",
+						Aliases: []string{"p"},
+						Usage:   "the namespace to initialize",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					keyids := cli.Args().Slice()
+					if len(keyids) == 0 {
+						return errors.New("no keyid provided")
+					}
+
+					namespace := strings.Trim(cli.String("path"), "/")
+
+					files, err := files()
+					if err != nil {
+						return err
+					}
+
+					err = files.Init(ctx, namespace, keyids)
+					if err != nil {
+						return err
+					}
+
+					if namespace == "" {
+						fmt.Fprintf(cli.App.Writer, "password store initialized for %s\n", strings.Join(keyids, ", "))
+					} else {
+						fmt.Fprintf(cli.App.Writer, "password store initialized for %s (%s)\n", strings.Join(keyids, ", "), namespace)
+					}
+
+					dir := filepath.Join(env.PASSWORD_STORE_DIR, namespace)
+					err = commit(fmt.Sprintf("Set GPG id to %s.", strings.Join(keyids, ", ")), filepath.Join(dir, ".gpg-id"))
+					if err != nil {
+						return err
+					}
+
+					secrets, err := files.List(ctx, namespace)
+					if err != nil {
+						return err
+					}
+
+					failed := 0
+					for _, secret := range secrets {
+						ok, err := secret.(*spass.SecretFile).Reencrypt(ctx)
+						if err != nil {
+							failed++
+							fmt.Fprintln(cli.App.ErrWriter, err)
+							continue
+						}
+
+						if ok {
+							fmt.Fprintf(cli.App.Writer, "secret '%s' re-encrypted\n", secret.FullName())
+						}
+					}
+
+					err = commit(fmt.Sprintf("Reencrypt password store using new GPG id %s.", strings.Join(keyids, ", ")), dir)
+					if err != nil {
+						return err
+					}
+
+					if failed > 0 {
+						return fmt.Errorf("%d secrets could not be re-encrypted", failed)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "list",
+				Aliases:   []string{"ls"},
+				ArgsUsage: "[namespace]",
+				Usage:     "list the secrets in the password store",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "tree",
+						Aliases: []string{"t"},
+						Value:   false,
+						Usage:   "show the secrets as a tree",
+					},
+					&cli.BoolFlag{
+						Name:    "flat",
+						Aliases: []string{"f"},
+						Value:   false,
+						Usage:   "show the full name of each secret on a line (default)",
+					},
+					&cli.BoolFlag{
+						Name:    "json",
+						Aliases: []string{"j"},
+						Value:   false,
+						Usage:   "show the secrets as json",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					namespace := strings.Trim(cli.Args().Get(0), "/")
+
+					secrets, err := store.List(ctx, namespace)
+					if err != nil {
+						return err
+					}
+
+					switch {
+					case cli.Bool("json"):
+						return printJSON(cli.App.Writer, secrets)
+					case cli.Bool("tree"):
+						title := namespace
+						if title == "" {
+							title = "Password Store"
+						}
+						printTree(cli.App.Writer, title, namespace, secrets)
+					default:
+						for _, secret := range secrets {
+							fmt.Fprintln(cli.App.Writer, secret.FullName())
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "pass",
+				ArgsUsage: "[name]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "copy",
+						Aliases: []string{"c"},
+						Usage:   "copy value to the clipboard",
+						Value:   false,
+					},
+				},
+				Usage: "show the password for the specified secret",
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("no secret found")
+					}
+
+					pass, err := secret.Password(ctx)
+					if err != nil {
+						return err
+					}
+
+					if cli.Bool("copy") {
+						err = copyToClipboard(pass)
+						if err != nil {
+							return err
+						}
+						fmt.Fprintln(cli.App.Writer, "password copied!")
+					} else {
+						fmt.Fprintln(cli.App.Writer, pass)
+					}
+
+					// Show otp if found
+					body, err := secret.Body(ctx)
+					if err != nil {
+						return err
+					}
+
+					otpauth := findOTP(body)
+					if otpauth == "" {
+						return nil
+					}
+
+					// Showing a counter based code would use it up, only spass otp does that.
+					if strings.HasPrefix(otpauth, "otpauth://hotp/") {
+						return nil
+					}
+
+					key, err := otp.NewKeyFromURL(otpauth)
+					if err != nil {
+						return fmt.Errorf("invalid otp set up in secret '%s'", secret.FullName())
+					}
+
+					code, left, err := currentTOTP(cli.App.Writer, key, cli.Bool("wait"))
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "%-10s valid for another %ds\n", code, left)
+
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				ArgsUsage: "[name]",
+				Usage:     "show all the info for the specified secret",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "rev",
+						Aliases: []string{"r"},
+						Usage:   "show the secret as it was in the git revision",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					if rev := cli.String("rev"); rev != "" {
+						_, body, err := revision(name, rev)
+						if err != nil {
+							return err
+						}
+
+						fmt.Fprintf(cli.App.Writer, "%s", body)
+						return nil
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					body, err := secret.Body(ctx)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "%s", body)
+					return nil
+				},
+			},
+			{
+				Name:      "history",
+				ArgsUsage: "[name]",
+				Usage:     "list the git revisions of the specified secret",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					repo, _, path, err := history(name)
+					if err != nil {
+						return err
+					}
+
+					revisions, err := repo.Log(ctx, path)
+					if err != nil {
+						return err
+					}
+
+					if len(revisions) == 0 {
+						return fmt.Errorf("no history found for secret '%s'", name)
+					}
+
+					for _, rev := range revisions {
+						fmt.Fprintf(cli.App.Writer, "%.8s  %s  %-20s  %s\n", rev.Hash, rev.Date.Format("2006-01-02 15:04"), rev.Author, rev.Subject)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "restore",
+				ArgsUsage: "[name]",
+				Usage:     "restore the specified secret to a previous git revision",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "rev",
+						Aliases:  []string{"r"},
+						Usage:    "the git revision to restore",
+						Required: true,
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					rev := cli.String("rev")
+					secret, body, err := revision(name, rev)
+					if err != nil {
+						return err
+					}
+
+					// Write instead of checking out the old file, so the secret is
+					// encrypted for the current recipients.
+					err = secret.Write(ctx, body)
+					if err != nil {
+						return err
+					}
+
+					err = commitSecret(fmt.Sprintf("Restore %s to revision %s.", secret.FullName(), rev), secret)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "secret '%s' restored to revision %s!\n", secret.FullName(), rev)
+
+					return nil
+				},
+			},
+			{
+				Name:      "qrcode",
+				ArgsUsage: "[name]",
+				Usage:     "show the otp qrcode",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					body, err := secret.Body(ctx)
+					if err != nil {
+						return err
+					}
+
+					otpauth := findOTP(body)
+					if otpauth == "" {
+						return fmt.Errorf("no otp set up in secret '%s'", secret.FullName())
+					}
+
+					qrterminal.GenerateWithConfig(otpauth, qrterminal.Config{
+						Level:     qrterminal.L,
+						Writer:    cli.App.Writer,
+						BlackChar: qrterminal.BLACK,
+						WhiteChar: qrterminal.WHITE,
+						QuietZone: 2,
+						// HalfBlocks: true,
+						WithSixel: true,
+					})
+
+					return nil
+				},
+			},
+			{
+				Name:      "scan",
+				ArgsUsage: "[name]",
+				Usage:     "scan the screen or an image for a qr code and store it",
+				Description: "When the qr code is a Google Authenticator export, all accounts in it are\n" +
+					"stored as separate secrets named after their issuer and account, in the\n" +
+					"namespace [name] if it is provided.",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "overwrite",
+						Aliases: []string{"o"},
+						Value:   false,
+						Usage:   "overwrite existing password if the secret already exists",
+					},
+					&cli.StringFlag{
+						Name:    "uri",
+						Aliases: []string{"u"},
+						Usage:   "use the otpauth:// or otpauth-migration:// uri instead of scanning the screen",
+					},
+					&cli.StringFlag{
+						Name:    "image",
+						Aliases: []string{"i"},
+						Usage:   "scan the png, jpeg or gif image instead of the screen, use - to read it from stdin",
+					},
+					&cli.BoolFlag{
+						Name:    "dry-run",
+						Aliases: []string{"n"},
+						Value:   false,
+						Usage:   "only show which secrets would be saved",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					overwrite := cli.Bool("overwrite")
+					dryRun := cli.Bool("dry-run")
+					name := cli.Args().Get(0)
+
+					var codes []string
+					var err error
+					switch {
+					case cli.String("uri") != "":
+						codes = []string{cli.String("uri")}
+					case cli.String("image") != "":
+						codes, err = scanImage(cli.String("image"), cli.App.Reader)
+					default:
+						codes, err = scanScreen()
+					}
+					if err != nil {
+						return err
+					}
+
+					// Stdin is already used for the image.
+					stdin := cli.App.Reader
+					if cli.String("image") == "-" {
+						stdin = nil
+					}
+
+					otpauth, err := chooseQR(codes, stdin, cli.App.ErrWriter)
+					if err != nil {
+						return err
+					}
+
+					if strings.HasPrefix(otpauth, migration.Prefix) {
+						accounts, err := migration.Parse(otpauth)
+						if err != nil {
+							return err
+						}
+
+						if len(accounts) == 0 {
+							return errors.New("no accounts found in the export")
+						}
+
+						changed := []string{}
+						seen := map[string]int{}
+						for _, account := range accounts {
+							target := otpName(name, account.Issuer, account.AccountName())
+
+							// Accounts with the same issuer and name should not overwrite each other.
+							seen[target]++
+							if seen[target] > 1 {
+								target = fmt.Sprintf("%s-%d", target, seen[target])
+							}
+
+							secret, err := saveOTP(ctx, store, target, account.URI(), overwrite, dryRun)
+							if errors.Is(err, errOTPExists) {
+								fmt.Fprintf(cli.App.Writer, "secret '%s' skipped, it already has an otp secret\n", target)
+								continue
+							}
+							if err != nil {
+								return err
+							}
+
+							if dryRun {
+								fmt.Fprintf(cli.App.Writer, "secret '%s' would be saved\n", target)
+								continue
+							}
+
+							if file, ok := secret.(*spass.SecretFile); ok {
+								changed = append(changed, file.Filename())
+							}
+
+							fmt.Fprintf(cli.App.Writer, "secret '%s' saved!\n", target)
+						}
+
+						if len(changed) == 0 {
+							return nil
+						}
+
+						return commit(fmt.Sprintf("Import %d OTP secrets to store.", len(changed)), changed...)
+					}
+
+					if !isOTP(otpauth) {
+						return fmt.Errorf("invalid otp parsed from qr code")
+					}
+
+					key, err := otp.NewKeyFromURL(otpauth)
+					if err != nil {
+						return fmt.Errorf("invalid otp parsed from qr code")
+					}
+
+					fmt.Fprintln(cli.App.Writer, "Issuer:", key.Issuer())
+					fmt.Fprintln(cli.App.Writer, "Account:", key.AccountName())
+
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := saveOTP(ctx, store, name, otpauth, overwrite, dryRun)
+					if err != nil {
+						return err
+					}
+
+					if dryRun {
+						fmt.Fprintf(cli.App.Writer, "secret '%s' would be saved\n", secret.FullName())
+						return nil
+					}
+
+					err = commitSecret(fmt.Sprintf("Add OTP secret for %s to store.", secret.FullName()), secret)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "secret '%s' saved!\n", secret.FullName())
+
+					return nil
+				},
+			},
+			{
+				Name:      "insert",
+				Aliases:   []string{"add"},
+				ArgsUsage: "[name]",
+				Usage:     "store an existing password as a secret under the provided name",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "multiline",
+						Aliases: []string{"m"},
+						Value:   false,
+						Usage:   "read the full contents of the secret until EOF",
+					},
+					&cli.BoolFlag{
+						Name:    "echo",
+						Aliases: []string{"e"},
+						Value:   false,
+						Usage:   "echo the password while typing it",
+					},
+					&cli.BoolFlag{
+						Name:    "overwrite",
+						Aliases: []string{"o"},
+						Value:   false,
+						Usage:   "overwrite the secret if it already exists",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, _ := store.Secret(ctx, name)
+					if secret != nil && !cli.Bool("overwrite") {
+						return fmt.Errorf("a secret with that name already exists, pass --overwrite to overwrite it")
+					}
+
+					content, err := prompt.Secret(name, prompt.Options{
+						Multiline: cli.Bool("multiline"),
+						Echo:      cli.Bool("echo"),
+						Stdin:     cli.App.Reader,
+					})
+					if err != nil {
+						return err
+					}
+
+					secret, err = store.NewSecret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					err = secret.Write(ctx, content)
+					if err != nil {
+						return err
+					}
+
+					err = commitSecret(fmt.Sprintf("Add given password for %s to store.", secret.FullName()), secret)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "secret '%s' saved!\n", secret.FullName())
+
+					password, _, _ := strings.Cut(content, "\n")
+					if password != "" && !isOTP(password) {
+						if result := strength.Estimate(password, secret.FullName()); result.Weak() {
+							fmt.Fprintf(cli.App.ErrWriter, "warning: this password is weak, estimated time to guess it: %s\n", result.CrackTimeDisplay())
+						}
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "generate",
+				ArgsUsage: "[name] [length]",
+				Usage:     "generate a new password and store as a secret under the provided name",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "lowercase",
+						Aliases: []string{"l"},
+						Value:   false,
+						Usage:   "use only lowercase characters",
+					},
+					&cli.BoolFlag{
+						Name:    "no-numbers",
+						Aliases: []string{"n"},
+						Value:   false,
+						Usage:   "do not use numbers",
+					},
+					&cli.BoolFlag{
+						Name:    "no-symbols",
+						Aliases: []string{"s"},
+						Value:   false,
+						Usage:   "do not use symbols",
+					},
+					&cli.BoolFlag{
+						Name:    "overwrite",
+						Aliases: []string{"o"},
+						Value:   false,
+						Usage:   "overwrite existing password if the secret already exists",
+					},
+					&cli.IntFlag{
+						Name:  "min-lower",
+						Value: 1,
+						Usage: "the minimum number of lowercase characters",
+					},
+					&cli.IntFlag{
+						Name:  "min-upper",
+						Value: 1,
+						Usage: "the minimum number of uppercase characters",
+					},
+					&cli.IntFlag{
+						Name:  "min-digits",
+						Value: 1,
+						Usage: "the minimum number of digits",
+					},
+					&cli.IntFlag{
+						Name:  "min-symbols",
+						Value: 1,
+						Usage: "the minimum number of symbols",
+					},
+					&cli.StringFlag{
+						Name:  "allowed",
+						Usage: "only use these characters",
+					},
+					&cli.StringFlag{
+						Name:  "forbidden",
+						Usage: "never use these characters",
+					},
+					&cli.BoolFlag{
+						Name:    "no-ambiguous",
+						Aliases: []string{"a"},
+						Value:   false,
+						Usage:   "do not use characters that are easily confused, like 0 and O",
+					},
+					&cli.IntFlag{
+						Name:  "max-repeat",
+						Usage: "the maximum number of times a character can be repeated in a row",
+					},
+					&cli.IntFlag{
+						Name:    "words",
+						Aliases: []string{"w"},
+						Usage:   "generate a passphrase with this many words instead",
+					},
+					&cli.StringFlag{
+						Name:  "wordlist",
+						Usage: "the file with the words to use for the passphrase, defaults to the EFF large wordlist",
+					},
+					&cli.StringFlag{
+						Name:  "separator",
+						Value: "-",
+						Usage: "what to put between the words of the passphrase",
+					},
+					&cli.BoolFlag{
+						Name:  "capitalize",
+						Value: false,
+						Usage: "capitalize the words of the passphrase",
+					},
+					&cli.BoolFlag{
+						Name:  "digit",
+						Value: false,
+						Usage: "add a digit to the passphrase",
+					},
+					&cli.BoolFlag{
+						Name:  "symbol",
+						Value: false,
+						Usage: "add a symbol to the passphrase",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, _ := store.Secret(ctx, name)
+					exists := secret != nil
+					if exists && !cli.Bool("overwrite") {
+						return fmt.Errorf("a secret with that name already exists, pass --overwrite to overwrite the password")
+					}
+
+					options, _, err := policy(name)
+					if err != nil {
+						return err
+					}
+
+					if length := cli.Args().Get(1); length != "" {
+						err = options.Set("length", length)
+						if err != nil {
+							return fmt.Errorf("invalid password length '%s'", length)
+						}
+					}
+
+					// Flags override the policy of the namespace.
+					for _, flag := range cli.Command.Flags {
+						key := flag.Names()[0]
+						if key == "overwrite" || !cli.IsSet(key) {
+							continue
+						}
+
+						err = options.Set(key, cli.String(key))
+						if err != nil {
+							return err
+						}
+					}
+
+					password, err := options.Generate()
+					if err != nil {
+						return err
+					}
+
+					secret, err = store.NewSecret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					err = secret.SetPassword(ctx, password)
+					if err != nil {
+						return err
+					}
+
+					message := fmt.Sprintf("Add generated password for %s.", secret.FullName())
+					if exists {
+						message = fmt.Sprintf("Replace generated password for %s.", secret.FullName())
+					}
+
+					err = commitSecret(message, secret)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintln(cli.App.Writer, password)
+					fmt.Fprintf(cli.App.ErrWriter, "estimated entropy: %.0f bits\n", strength.Estimate(password).Entropy)
+					return nil
+				},
+			},
+			{
+				Name:  "policy",
+				Usage: "manage the policies used to generate passwords",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "show",
+						ArgsUsage: "[name]",
+						Usage:     "show the policy used to generate the password for the secret with the provided name",
+						Action: func(cli *cli.Context) error {
+							options, filename, err := policy(cli.Args().Get(0))
+							if err != nil {
+								return err
+							}
+
+							if filename == "" {
+								fmt.Fprintln(cli.App.ErrWriter, "no policy file found, using the default policy")
+							} else {
+								fmt.Fprintf(cli.App.ErrWriter, "using policy '%s'\n", filename)
+							}
+
+							fmt.Fprint(cli.App.Writer, options)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:      "edit",
+				ArgsUsage: "[name]",
+				Usage:     "edit the contents of the specified secret",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					body, err := secret.Body(ctx)
+					if err != nil {
+						return err
+					}
+
+					b, err := editor.Edit(env.EDITOR, body)
+					if err != nil {
+						return err
+					}
+
+					err = secret.Write(ctx, string(b))
+					if err != nil {
+						return err
+					}
+
+					err = commitSecret(fmt.Sprintf("Edit password for %s using %s.", secret.FullName(), env.EDITOR), secret)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "secret '%s' saved!\n", secret.FullName())
+
+					return nil
+				},
+			},
+			{
+				Name:      "remove",
+				Aliases:   []string{"rm"},
+				ArgsUsage: "[name]",
+				Usage:     "delete a secret in the store",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					err = secret.Remove(ctx)
+					if err != nil {
+						return err
+					}
+
+					return commitSecret(fmt.Sprintf("Remove %s from store.", secret.FullName()), secret)
+				},
+			},
+			{
+				Name:      "move",
+				Aliases:   []string{"mv"},
+				ArgsUsage: "[from] [to]",
+				Usage:     "move or rename a secret or namespace, re-encrypting it if needed",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Value:   false,
+						Usage:   "overwrite existing secrets at the destination",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					from := cli.Args().Get(0)
+					to := cli.Args().Get(1)
+					if from == "" || to == "" {
+						return errors.New("no source or destination provided")
+					}
+
+					files, err := files()
+					if err != nil {
+						return err
+					}
+
+					changed, err := files.Move(ctx, from, to, cli.Bool("force"))
+					if err != nil {
+						return err
+					}
+
+					err = commit(fmt.Sprintf("Rename %s to %s.", from, to), changed...)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "'%s' moved to '%s'!\n", from, to)
+
+					return nil
+				},
+			},
+			{
+				Name:      "copy",
+				Aliases:   []string{"cp"},
+				ArgsUsage: "[from] [to]",
+				Usage:     "copy a secret or namespace, re-encrypting it if needed",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Value:   false,
+						Usage:   "overwrite existing secrets at the destination",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					from := cli.Args().Get(0)
+					to := cli.Args().Get(1)
+					if from == "" || to == "" {
+						return errors.New("no source or destination provided")
+					}
+
+					files, err := files()
+					if err != nil {
+						return err
+					}
+
+					changed, err := files.Copy(ctx, from, to, cli.Bool("force"))
+					if err != nil {
+						return err
+					}
+
+					err = commit(fmt.Sprintf("Copy %s to %s.", from, to), changed...)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "'%s' copied to '%s'!\n", from, to)
+
+					return nil
+				},
+			},
+			{
+				Name:            "git",
+				ArgsUsage:       "[git-command-args...]",
+				Usage:           "run a git command in the password store",
+				SkipFlagParsing: true,
+				Action: func(cli *cli.Context) error {
+					args := cli.Args().Slice()
+
+					repo := &git.Repo{
+						Dir:  env.PASSWORD_STORE_DIR,
+						Path: "git",
+					}
+
+					err := repo.Run(ctx, args...)
+					if err != nil {
+						return err
+					}
+
+					if len(args) == 0 || args[0] != "init" {
+						return nil
+					}
+
+					// Set up the new repository like pass does.
+					err = repo.Commit(ctx, "Add current contents of password store.", ".")
+					if err != nil {
+						return err
+					}
+
+					err = os.WriteFile(filepath.Join(env.PASSWORD_STORE_DIR, ".gitattributes"), []byte("*.gpg diff=gpg\n"), 0644)
+					if err != nil {
+						return fmt.Errorf("could not write .gitattributes")
+					}
+
+					err = repo.Commit(ctx, "Configure git repository for gpg file diff.", ".gitattributes")
+					if err != nil {
+						return err
+					}
+
+					err = repo.Run(ctx, "config", "--local", "diff.gpg.binary", "true")
+					if err != nil {
+						return err
+					}
+
+					return repo.Run(ctx, "config", "--local", "diff.gpg.textconv", "gpg -d")
+				},
+			},
+			{
+				Name:      "get",
+				ArgsUsage: "[name] [key]",
+				Usage:     "get the value of the key in the specified secret",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "case-insensitive",
+						Aliases: []string{"i"},
+						Value:   false,
+						Usage:   "make the key match case insensitive",
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					caseInsensitive := cli.Bool("case-insensitive")
+
+					key := cli.Args().Get(1)
+					if name == "" {
+						return errors.New("no key provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					pairs, err := secret.Pairs(ctx)
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintln(cli.App.Writer, caseInsensitive)
+
+					ok := false
+					for _, pair := range pairs {
+						if caseInsensitive {
+							if strings.EqualFold(pair.Key, key) {
+								ok = true
+								fmt.Fprintln(cli.App.Writer, pair.Value)
+							}
+						} else {
+							if pair.Key == key {
+								ok = true
+								fmt.Fprintln(cli.App.Writer, pair.Value)
+							}
+						}
+					}
+
+					if !ok {
+						return fmt.Errorf("key '%s' not found in secret '%s'", key, name)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "otp",
+				ArgsUsage: "[name]",
+				Usage:     "get an one time password from the specified secret",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "copy",
+						Aliases: []string{"c"},
+						Usage:   "copy value to the clipboard",
+						Value:   false,
+					},
+					&cli.BoolFlag{
+						Name:    "wait",
+						Aliases: []string{"w"},
+						Usage:   "wait for a new token if the current one is about to expire",
+						Value:   false,
+					},
+					&cli.BoolFlag{
+						Name:    "all",
+						Aliases: []string{"a"},
+						Usage:   "show a live dashboard of all one time passwords, like otp watch",
+						Value:   false,
+					},
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "watch",
+						ArgsUsage: "[namespace]",
+						Usage:     "show a live dashboard of the one time passwords in the namespace",
+						Action: func(cli *cli.Context) error {
+							return watch(ctx, store, cli.Args().Get(0), cli.App.Writer, cli.App.ErrWriter)
+						},
+					},
+				},
+				Action: func(cli *cli.Context) error {
+					if cli.Bool("all") {
+						return watch(ctx, store, cli.Args().Get(0), cli.App.Writer, cli.App.ErrWriter)
+					}
+
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					body, err := secret.Body(ctx)
+					if err != nil {
+						return err
+					}
+
+					otpauth := findOTP(body)
+					if otpauth == "" {
+						return fmt.Errorf("no otp set up in secret '%s'", secret.FullName())
+					}
+
+					if strings.HasPrefix(otpauth, "otpauth://hotp/") {
+						code, err := nextHOTP(ctx, secret, otpauth)
+						if err != nil {
+							return err
+						}
+
+						err = commitSecret(fmt.Sprintf("Increment HOTP counter for %s.", secret.FullName()), secret)
+						if err != nil {
+							return err
+						}
+
+						fmt.Fprintln(cli.App.Writer, code)
+
+						if cli.Bool("copy") {
+							err = copyToClipboard(code)
+							if err != nil {
+								return err
+							}
+							fmt.Fprintln(cli.App.Writer, "code copied to clipboard!")
+						}
+
+						return nil
+					}
+
+					key, err := otp.NewKeyFromURL(otpauth)
+					if err != nil {
+						return fmt.Errorf("invalid otp set up in secret '%s'", secret.FullName())
+					}
+
+					code, left, err := currentTOTP(cli.App.Writer, key, cli.Bool("wait"))
+					if err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cli.App.Writer, "%-10s valid for another %ds\n", code, left)
+
+					if cli.Bool("copy") {
+						err = copyToClipboard(code)
+						if err != nil {
+							return err
+						}
+						fmt.Fprintf(cli.App.Writer, "%10s copied to clipboard!\n", " ")
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:      "pwnd",
+				ArgsUsage: "[name]",
+				Usage:     "check if the password in the specified secret was pwnd",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					name := cli.Args().Get(0)
+					if name == "" {
+						return errors.New("no name provided")
+					}
+
+					secret, err := store.Secret(ctx, name)
+					if err != nil {
+						return err
+					}
+
+					if secret == nil {
+						return errors.New("unreachable")
+					}
+
+					password, err := secret.Password(ctx)
+					if err != nil {
+						return err
+					}
+
+					checker, err := pwndChecker()
+					if err != nil {
+						return err
+					}
+
+					count, err := checker.Check(ctx, password)
+					if err != nil {
+						return err
+					}
+
+					if count > 0 {
+						fmt.Fprintf(cli.App.Writer, "this password has been pwnd %d times, generate a new one\n", count)
+					} else {
+						fmt.Fprintln(cli.App.Writer, "this password has not been pwnd!")
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:  "audit",
+				Usage: "audit the secrets in the password store",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "pwnd",
+						ArgsUsage: "[namespace]",
+						Usage:     "check if the passwords in the namespace were pwnd, exits with status 1 if any were",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "json",
+								Aliases: []string{"j"},
+								Value:   false,
+								Usage:   "show the results as json",
+							},
+							&cli.IntFlag{
+								Name:    "concurrency",
+								Aliases: []string{"c"},
+								Value:   4,
+								Usage:   "the number of ranges to fetch at the same time",
+							},
+							&cli.Float64Flag{
+								Name:  "rate",
+								Value: 10,
+								Usage: "the maximum number of ranges to fetch per second, 0 for no limit, not used for HAVEIBEENPWND_DATABASE unless set",
+							},
+						},
+						Action: func(cli *cli.Context) error {
+							namespace := strings.Trim(cli.Args().Get(0), "/")
+
+							checker, err := pwndChecker()
+							if err != nil {
+								return err
+							}
+
+							var interval time.Duration
+							if rate := cli.Float64("rate"); rate > 0 && (env.HAVEIBEENPWND_DATABASE == "" || cli.IsSet("rate")) {
+								interval = time.Duration(float64(time.Second) / rate)
+							}
+
+							results, err := auditPwnd(ctx, store, checker, namespace, cli.Int("concurrency"), interval)
+							if err != nil {
+								return err
+							}
+
+							if cli.Bool("json") {
+								err = printPwndJSON(cli.App.Writer, results)
+							} else {
+								err = printPwndTable(cli.App.Writer, results)
+							}
+							if err != nil {
+								return err
+							}
+
+							count, failed := 0, 0
+							for _, result := range results {
+								if result.Count > 0 {
+									count++
+								}
+								if result.Error != "" {
+									failed++
+								}
+							}
+
+							if count > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "%d of %d passwords have been pwnd\n", count, len(results))
+							}
+
+							if failed > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "%d of %d passwords could not be checked\n", failed, len(results))
+							}
+
+							if count > 0 || failed > 0 {
+								return errAudit
+							}
+
+							return nil
+						},
+					},
+					{
+						Name:      "strength",
+						ArgsUsage: "[namespace]",
+						Usage:     "estimate how hard the passwords in the namespace are to guess, exits with status 1 if any are weak",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "json",
+								Aliases: []string{"j"},
+								Value:   false,
+								Usage:   "show the results as json",
+							},
+							&cli.IntFlag{
+								Name:  "min-score",
+								Value: 3,
+								Usage: "the minimum score from 0 to 4 of a password that is not weak",
+							},
+						},
+						Action: func(cli *cli.Context) error {
+							namespace := strings.Trim(cli.Args().Get(0), "/")
+
+							results, err := auditStrength(ctx, store, namespace)
+							if err != nil {
+								return err
+							}
+
+							if cli.Bool("json") {
+								err = printStrengthJSON(cli.App.Writer, results)
+							} else {
+								err = printStrengthTable(cli.App.Writer, results)
+							}
+							if err != nil {
+								return err
+							}
+
+							weak, failed := 0, 0
+							for _, result := range results {
+								switch {
+								case result.Error != "":
+									failed++
+								case result.Score < cli.Int("min-score"):
+									weak++
+								}
+							}
+
+							if weak > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "%d of %d passwords are weak\n", weak, len(results))
+							}
+
+							if failed > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "%d of %d passwords could not be checked\n", failed, len(results))
+							}
+
+							if weak > 0 || failed > 0 {
+								return errAudit
+							}
+
+							return nil
+						},
+					},
+					{
+						Name:      "reuse",
+						ArgsUsage: "[namespace]",
+						Usage:     "find secrets in the namespace with the same or similar passwords, exits with status 1 if any are found",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "json",
+								Aliases: []string{"j"},
+								Value:   false,
+								Usage:   "show the results as json",
+							},
+						},
+						Action: func(cli *cli.Context) error {
+							namespace := strings.Trim(cli.Args().Get(0), "/")
+
+							groups, failed, err := auditReuse(ctx, store, namespace)
+							if err != nil {
+								return err
+							}
+
+							for _, secret := range failed {
+								fmt.Fprintf(cli.App.ErrWriter, "skipping secret '%s': %s\n", secret.name, secret.err)
+							}
+
+							if cli.Bool("json") {
+								err = printReuseJSON(cli.App.Writer, groups)
+								if err != nil {
+									return err
+								}
+							} else {
+								printReuse(cli.App.Writer, groups)
+							}
+
+							if len(groups) > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "found %d groups of reused passwords\n", len(groups))
+							}
+
+							if len(failed) > 0 {
+								fmt.Fprintf(cli.App.ErrWriter, "%d secrets could not be checked\n", len(failed))
+							}
+
+							if len(groups) > 0 || len(failed) > 0 {
+								return errAudit
+							}
+
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:      "search",
+				ArgsUsage: "[query]",
+				Usage:     "search for a secret containg the query",
+				Flags:     []cli.Flag{},
+				Action: func(cli *cli.Context) error {
+					arg := cli.Args().Get(0)
+
+					parts := strings.Split(arg, ":")
+					key := parts[0]
+					val := parts[1]
+
+					secrets, err := store.List(ctx, "")
+					if err != nil {
+						return err
+					}
+
+					ok := false
+					for _, secret := range secrets {
+						pairs, err := secret.Pairs(ctx)
+						if err != nil {
+							return err
+						}
+
+						for _, pair := range pairs {
+							if strings.ToLower(key) == strings.ToLower(pair.Key) {
+								if strings.Contains(strings.ToLower(pair.Value), strings.ToLower(val)) {
+									ok = true
+									fmt.Fprintf(cli.App.Writer, "match found in secret '%s':\n", secret.FullName())
+									fmt.Fprintf(cli.App.Writer, "%s: %s\n", pair.Key, pair.Value)
+								}
+							}
+						}
+					}
+					if ok {
+						return nil
+					} else {
+						return fmt.Errorf("no match found")
+					}
+				},
+			},
+		},
+	}
+}
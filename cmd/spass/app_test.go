@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romeovs/spass/pkg/spass"
+	"github.com/urfave/cli/v2"
+)
+
+// run runs spass with the arguments against the store, with stdin as its input.
+func run(t *testing.T, env *spass.Env, store spass.Store, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	app := newApp(context.Background(), env, store)
+	app.Reader = strings.NewReader(stdin)
+	app.Writer = stdout
+	app.ErrWriter = stderr
+	app.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	err := app.Run(append([]string{"spass"}, args...))
+	return stdout.String(), stderr.String(), err
+}
+
+func newTestStore() (*spass.Env, *spass.MemoryStore) {
+	return &spass.Env{}, spass.NewMemoryStore(&spass.TestCrypto{}, "test")
+}
+
+func body(t *testing.T, store spass.Store, name string) string {
+	t.Helper()
+
+	secret, err := store.Secret(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := secret.Body(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestInsertAndShow(t *testing.T) {
+	env, store := newTestStore()
+
+	stdout, _, err := run(t, env, store, "hunter2\n", "insert", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "secret 'web/example' saved!\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+
+	stdout, _, err = run(t, env, store, "", "pass", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "hunter2\n" {
+		t.Errorf("expected the password, got %q", stdout)
+	}
+
+	_, _, err = run(t, env, store, "hunter3\n", "insert", "web/example")
+	if err == nil {
+		t.Error("expected an error when inserting an existing secret")
+	}
+	if got := body(t, store, "web/example"); got != "hunter2\n" {
+		t.Errorf("secret was overwritten with %q", got)
+	}
+
+	_, _, err = run(t, env, store, "hunter3\n", "insert", "--overwrite", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, store, "web/example"); got != "hunter3\n" {
+		t.Errorf("secret was not overwritten, got %q", got)
+	}
+}
+
+func TestInsertMultiline(t *testing.T) {
+	env, store := newTestStore()
+
+	content := "correct horse battery staple\nusername: john\n"
+	_, stderr, err := run(t, env, store, content, "insert", "--multiline", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stderr != "" {
+		t.Errorf("unexpected warning %q", stderr)
+	}
+
+	stdout, _, err := run(t, env, store, "", "show", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != content {
+		t.Errorf("expected the full secret, got %q", stdout)
+	}
+}
+
+func TestInsertWeakPassword(t *testing.T) {
+	env, store := newTestStore()
+
+	_, stderr, err := run(t, env, store, "password1\n", "insert", "weak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stderr, "warning: this password is weak") {
+		t.Errorf("expected a warning, got %q", stderr)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	env, store := newTestStore()
+
+	stdout, stderr, err := run(t, env, store, "", "generate", "bank", "24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := strings.TrimSuffix(stdout, "\n")
+	if len(password) != 24 {
+		t.Errorf("expected a password of 24 characters, got %q", password)
+	}
+	if got := body(t, store, "bank"); got != password {
+		t.Errorf("expected the generated password to be stored, got %q", got)
+	}
+	if !strings.HasPrefix(stderr, "estimated entropy: ") {
+		t.Errorf("expected the entropy, got %q", stderr)
+	}
+
+	_, _, err = run(t, env, store, "", "generate", "bank")
+	if err == nil {
+		t.Error("expected an error when generating an existing secret")
+	}
+}
+
+func TestGenerateKeepsSecret(t *testing.T) {
+	env, store := newTestStore()
+
+	_, _, err := run(t, env, store, "old\nusername: john\n", "insert", "--multiline", "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := run(t, env, store, "", "generate", "--overwrite", "--no-symbols", "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := strings.TrimSuffix(stdout, "\n")
+	if strings.ContainsAny(password, "!@#$%^&*") {
+		t.Errorf("expected a password without symbols, got %q", password)
+	}
+	if got := body(t, store, "bank"); got != password+"\nusername: john\n" {
+		t.Errorf("expected the rest of the secret to be kept, got %q", got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	env, store := newTestStore()
+
+	_, _, err := run(t, env, store, "hunter2\n", "insert", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = run(t, env, store, "", "rm", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Secret(context.Background(), "web/example")
+	if err == nil {
+		t.Error("expected the secret to be removed")
+	}
+
+	_, _, err = run(t, env, store, "", "rm", "web/example")
+	if err == nil {
+		t.Error("expected an error when removing a missing secret")
+	}
+}
+
+func TestPassDoesNotAdvanceHOTP(t *testing.T) {
+	env, store := newTestStore()
+
+	content := "hunter2\notpauth://hotp/example?secret=JBSWY3DPEHPK3PXP&counter=1\n"
+	_, _, err := run(t, env, store, content, "insert", "--multiline", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := run(t, env, store, "", "pass", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "hunter2\n" {
+		t.Errorf("expected only the password, got %q", stdout)
+	}
+	if got := body(t, store, "web/example"); got != content {
+		t.Errorf("expected the counter to be unchanged, got %q", got)
+	}
+
+	_, _, err = run(t, env, store, "", "otp", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, store, "web/example"); !strings.Contains(got, "counter=2") {
+		t.Errorf("expected otp to advance the counter, got %q", got)
+	}
+}
+
+func TestMove(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("test\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &spass.Env{PASSWORD_STORE_DIR: dir}
+	store := spass.NewFileStoreWithCrypto(env, &spass.TestCrypto{})
+
+	for _, name := range []string{"web/example", "web/other", "mail"} {
+		_, _, err := run(t, env, store, name+"\n", "insert", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, _, err := run(t, env, store, "", "mv", "web/example", "sites/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "'web/example' moved to 'sites/example'!\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if got := body(t, store, "sites/example"); got != "web/example\n" {
+		t.Errorf("expected the moved secret, got %q", got)
+	}
+	if _, err := store.Secret(context.Background(), "web/example"); err == nil {
+		t.Error("expected the secret to be moved")
+	}
+
+	_, _, err = run(t, env, store, "", "mv", "web/other", "mail")
+	if err == nil {
+		t.Error("expected an error when moving to an existing secret")
+	}
+
+	_, _, err = run(t, env, store, "", "mv", "--force", "web/other", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := body(t, store, "mail"); got != "web/other\n" {
+		t.Errorf("expected the secret to be overwritten, got %q", got)
+	}
+}
+
+func TestMoveInMemory(t *testing.T) {
+	env, store := newTestStore()
+
+	_, _, err := run(t, env, store, "hunter2\n", "insert", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = run(t, env, store, "", "mv", "web/example", "sites/example")
+	if err == nil || err.Error() != "this command only works for a password store on disk" {
+		t.Errorf("expected an error for the memory store, got %v", err)
+	}
+}
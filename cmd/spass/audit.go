@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/romeovs/spass/pkg/pwnd"
+	"github.com/romeovs/spass/pkg/spass"
+	"github.com/romeovs/spass/pkg/strength"
+	"github.com/urfave/cli/v2"
+)
+
+// errAudit makes spass exit with status 1 after an audit found problems,
+// the audit already reported them.
+var errAudit = cli.Exit("", 1)
+
+// maxAttempts is how often a range is fetched when it is rate limited.
+const maxAttempts = 3
+
+// auditSecret is a password to audit.
+type auditSecret struct {
+	name     string
+	password string
+
+	// Why the secret could not be decrypted.
+	err error
+}
+
+// auditSecrets decrypts the passwords of all secrets in the namespace.
+// Secrets without a password, like otp secrets, are skipped.
+func auditSecrets(ctx context.Context, store spass.Store, namespace string) ([]*auditSecret, error) {
+	secrets, err := store.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*auditSecret{}
+	for _, secret := range secrets {
+		password, err := secret.Password(ctx)
+		if errors.Is(err, spass.ErrNoPassword) {
+			continue
+		}
+		if err != nil {
+			res = append(res, &auditSecret{
+				name: secret.FullName(),
+				err:  err,
+			})
+			continue
+		}
+
+		res = append(res, &auditSecret{
+			name:     secret.FullName(),
+			password: password,
+		})
+	}
+
+	return res, nil
+}
+
+// pwndResult is the result of checking the password of a secret.
+type pwndResult struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+
+	hash string
+}
+
+// auditPwnd checks the passwords of all secrets in the namespace.
+//
+// Every range of hashes is only fetched once, by at most concurrency workers at a time,
+// and the fetches are spaced by the interval to stay below rate limits.
+func auditPwnd(ctx context.Context, store spass.Store, checker pwnd.Checker, namespace string, concurrency int, interval time.Duration) ([]*pwndResult, error) {
+	secrets, err := auditSecrets(ctx, store, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*pwndResult{}
+	seen := map[string]bool{}
+	prefixes := []string{}
+	for _, secret := range secrets {
+		result := &pwndResult{
+			Name: secret.name,
+		}
+		results = append(results, result)
+
+		if secret.err != nil {
+			result.Error = secret.err.Error()
+			continue
+		}
+
+		result.hash = checker.Hash().Sum(secret.password)
+
+		prefix := result.hash[:pwnd.PrefixLength]
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var limit <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	queue := make(chan string)
+	go func() {
+		defer close(queue)
+		for _, prefix := range prefixes {
+			select {
+			case queue <- prefix:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ranges := map[string]map[string]int{}
+	failed := map[string]error{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prefix := range queue {
+				if limit != nil {
+					select {
+					case <-limit:
+					case <-ctx.Done():
+						return
+					}
+				}
+
+				hashes, err := fetchRange(ctx, checker, prefix)
+
+				mu.Lock()
+				ranges[prefix] = hashes
+				if err != nil {
+					failed[prefix] = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.hash == "" {
+			continue
+		}
+
+		prefix := result.hash[:pwnd.PrefixLength]
+		if err := failed[prefix]; err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		result.Count = ranges[prefix][result.hash[pwnd.PrefixLength:]]
+	}
+
+	// The most pwnd passwords go first.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Count > results[j].Count
+	})
+
+	return results, nil
+}
+
+// fetchRange fetches the range, waiting and trying again when it is rate limited.
+func fetchRange(ctx context.Context, checker pwnd.Checker, prefix string) (map[string]int, error) {
+	for attempt := 1; ; attempt++ {
+		hashes, err := checker.Range(ctx, prefix)
+
+		var limited *pwnd.RateLimitError
+		if !errors.As(err, &limited) || attempt == maxAttempts {
+			return hashes, err
+		}
+
+		wait := limited.RetryAfter
+		if wait == 0 {
+			wait = time.Duration(attempt) * time.Second
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// printPwndTable prints the results of the audit as a table.
+func printPwndTable(w io.Writer, results []*pwndResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECRET\tPWND")
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			fmt.Fprintf(tw, "%s\terror: %s\n", result.Name, result.Error)
+		case result.Count > 0:
+			fmt.Fprintf(tw, "%s\t%d times\n", result.Name, result.Count)
+		default:
+			fmt.Fprintf(tw, "%s\tno\n", result.Name)
+		}
+	}
+	return tw.Flush()
+}
+
+// printPwndJSON prints the results of the audit as a json array.
+func printPwndJSON(w io.Writer, results []*pwndResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// strengthResult is the estimated strength of the password of a secret.
+type strengthResult struct {
+	Name      string   `json:"name"`
+	Score     int      `json:"score"`
+	Entropy   float64  `json:"entropy"`
+	CrackTime string   `json:"crack_time"`
+	Patterns  []string `json:"patterns"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// auditStrength estimates the strength of the passwords of all secrets in the namespace.
+// The name of a secret counts as a word the password should not be based on.
+func auditStrength(ctx context.Context, store spass.Store, namespace string) ([]*strengthResult, error) {
+	secrets, err := auditSecrets(ctx, store, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*strengthResult{}
+	for _, secret := range secrets {
+		result := &strengthResult{
+			Name:     secret.name,
+			Patterns: []string{},
+		}
+		results = append(results, result)
+
+		if secret.err != nil {
+			result.Error = secret.err.Error()
+			continue
+		}
+
+		estimate := strength.Estimate(secret.password, secret.name)
+		result.Score = estimate.Score
+		result.Entropy = math.Round(estimate.Entropy*10) / 10
+		result.CrackTime = estimate.CrackTimeDisplay()
+		result.Patterns = estimate.Patterns()
+	}
+
+	// The weakest passwords go first.
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Error != "" || results[j].Error != "" {
+			return results[j].Error != "" && results[i].Error == ""
+		}
+		return results[i].Entropy < results[j].Entropy
+	})
+
+	return results, nil
+}
+
+// printStrengthTable prints the results of the audit as a table.
+func printStrengthTable(w io.Writer, results []*strengthResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECRET\tSCORE\tENTROPY\tCRACK TIME\tPATTERNS")
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(tw, "%s\terror: %s\t\t\t\n", result.Name, result.Error)
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s\t%d/4\t%.0f bits\t%s\t%s\n", result.Name, result.Score, result.Entropy, result.CrackTime, strings.Join(result.Patterns, ", "))
+	}
+	return tw.Flush()
+}
+
+// printStrengthJSON prints the results of the audit as a json array.
+func printStrengthJSON(w io.Writer, results []*strengthResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// reuseGroup is a group of secrets with the same or similar passwords.
+type reuseGroup struct {
+	// Either same or similar.
+	Kind    string   `json:"kind"`
+	Secrets []string `json:"secrets"`
+}
+
+// auditReuse finds the secrets in the namespace that share a password, or that have
+// passwords that only differ in their case or the numbers and symbols around them,
+// like Summer2023! and summer2024.
+//
+// Passwords are only compared by their HMAC, with a random key that is thrown away
+// after the audit, so the groups do not reveal anything about the passwords.
+func auditReuse(ctx context.Context, store spass.Store, namespace string) ([]*reuseGroup, []*auditSecret, error) {
+	secrets, err := auditSecrets(ctx, store, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sum := func(password string) string {
+		mac := hmac.New(sha256.New, key)
+		io.WriteString(mac, password)
+		return string(mac.Sum(nil))
+	}
+
+	same := map[string][]string{}
+	similar := map[string][]string{}
+	hashes := map[string]string{}
+	order := []string{}
+	failed := []*auditSecret{}
+	for _, secret := range secrets {
+		if secret.err != nil {
+			failed = append(failed, secret)
+			continue
+		}
+
+		hash := sum(secret.password)
+		if len(same[hash]) == 0 {
+			order = append(order, hash)
+		}
+		same[hash] = append(same[hash], secret.name)
+		hashes[secret.name] = hash
+
+		if normalized := normalizePassword(secret.password); len([]rune(normalized)) >= 4 {
+			near := sum(normalized)
+			similar[near] = append(similar[near], secret.name)
+		}
+	}
+
+	groups := []*reuseGroup{}
+	for _, hash := range order {
+		if len(same[hash]) > 1 {
+			groups = append(groups, &reuseGroup{
+				Kind:    "same",
+				Secrets: same[hash],
+			})
+		}
+	}
+
+	for _, names := range similar {
+		// Passwords that are exactly the same are already reported.
+		distinct := map[string]bool{}
+		for _, name := range names {
+			distinct[hashes[name]] = true
+		}
+
+		if len(distinct) > 1 {
+			groups = append(groups, &reuseGroup{
+				Kind:    "similar",
+				Secrets: names,
+			})
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Kind != groups[j].Kind {
+			return groups[i].Kind == "same"
+		}
+		return groups[i].Secrets[0] < groups[j].Secrets[0]
+	})
+
+	return groups, failed, nil
+}
+
+// normalizePassword leaves out the parts of a password that are commonly changed
+// when it is reused: the case and the numbers and symbols at the start and end.
+func normalizePassword(password string) string {
+	return strings.TrimFunc(strings.ToLower(password), func(c rune) bool {
+		return !unicode.IsLetter(c)
+	})
+}
+
+// printReuse prints the groups of secrets that share a password.
+func printReuse(w io.Writer, groups []*reuseGroup) {
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if group.Kind == "same" {
+			fmt.Fprintf(w, "%d secrets have the same password:\n", len(group.Secrets))
+		} else {
+			fmt.Fprintf(w, "%d secrets have similar passwords:\n", len(group.Secrets))
+		}
+
+		for _, name := range group.Secrets {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+	}
+}
+
+// printReuseJSON prints the groups of secrets that share a password as a json array.
+func printReuseJSON(w io.Writer, groups []*reuseGroup) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(groups)
+}
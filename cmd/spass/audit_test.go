@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/romeovs/spass/pkg/pwnd"
+	"github.com/romeovs/spass/pkg/spass"
+)
+
+func TestNormalizePassword(t *testing.T) {
+	tests := map[string]string{
+		"Summer2023!":  "summer",
+		"summer2024":   "summer",
+		"!!Summer":     "summer",
+		"2023-summer?": "summer",
+		"a1b2c3":       "a1b2c",
+		"abc":          "abc",
+		"p4ssw0rd!":    "p4ssw0rd",
+		"123456":       "",
+	}
+
+	for password, want := range tests {
+		if got := normalizePassword(password); got != want {
+			t.Errorf("normalizePassword(%q) = %q, want %q", password, got, want)
+		}
+	}
+}
+
+func TestAuditReuse(t *testing.T) {
+	ctx := context.Background()
+	store := spass.NewMemoryStore(&spass.TestCrypto{}, "test")
+
+	secrets := map[string]string{
+		"bank":         "Summer2023!\n",
+		"mail":         "summer2024\n",
+		"shop":         "Summer2023!\nusername: john\n",
+		"web/a":        "a1b2c3\n",
+		"web/b":        "abc\n",
+		"web/c":        "k3y!k3y\n",
+		"web/d":        "K3Y!k3y2\n",
+		"web/pin":      "1234\n",
+		"web/otherpin": "5678\n",
+		"web/otp":      "otpauth://totp/example?secret=JBSWY3DPEHPK3PXP\n",
+		"web/empty":    "\nusername: john\n",
+	}
+
+	for name, body := range secrets {
+		secret, err := store.NewSecret(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = secret.Write(ctx, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, failed, err := auditReuse(ctx, store, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("expected all secrets to be decrypted, %d failed", len(failed))
+	}
+
+	want := []*reuseGroup{
+		{Kind: "same", Secrets: []string{"bank", "shop"}},
+		{Kind: "similar", Secrets: []string{"bank", "mail", "shop"}},
+		{Kind: "similar", Secrets: []string{"web/c", "web/d"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		for _, group := range groups {
+			t.Logf("%s: %v", group.Kind, group.Secrets)
+		}
+		t.Errorf("unexpected groups")
+	}
+
+	groups, _, err = auditReuse(ctx, store, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []*reuseGroup{
+		{Kind: "similar", Secrets: []string{"web/c", "web/d"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		for _, group := range groups {
+			t.Logf("%s: %v", group.Kind, group.Secrets)
+		}
+		t.Errorf("unexpected groups in namespace")
+	}
+}
+
+func TestAuditReuseCommand(t *testing.T) {
+	env, store := newTestStore()
+
+	for _, name := range []string{"one", "two"} {
+		_, _, err := run(t, env, store, "correct horse battery staple\n", "insert", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, stderr, err := run(t, env, store, "", "audit", "reuse")
+	if err == nil {
+		t.Error("expected the audit to fail")
+	}
+	if stdout != "2 secrets have the same password:\n  one\n  two\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if stderr != "found 1 groups of reused passwords\n" {
+		t.Errorf("unexpected summary %q", stderr)
+	}
+}
+
+func TestAuditReuseCommandFailed(t *testing.T) {
+	dir := t.TempDir()
+	for name, id := range map[string]string{".gpg-id": "test", "locked/.gpg-id": "other"} {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(id+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	crypto := &spass.TestCrypto{}
+	env := &spass.Env{PASSWORD_STORE_DIR: dir}
+	store := spass.NewFileStoreWithCrypto(env, crypto)
+
+	for _, name := range []string{"one", "locked/two"} {
+		_, _, err := run(t, env, store, "correct horse battery staple\n", "insert", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The secret in locked is encrypted for someone else.
+	crypto.Identities = []string{"test"}
+
+	stdout, stderr, err := run(t, env, store, "", "audit", "reuse")
+	if err != errAudit {
+		t.Errorf("expected the audit to fail, got %v", err)
+	}
+	if stdout != "" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if stderr != "skipping secret 'locked/two': could not decrypt: no matching identity\n1 secrets could not be checked\n" {
+		t.Errorf("expected only the failed secrets in the summary, got %q", stderr)
+	}
+}
+
+func TestAuditPwndCommand(t *testing.T) {
+	pwned := pwnd.SHA1.Sum("hunter2")
+	available := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if strings.TrimPrefix(r.URL.Path, "/range/") == pwned[:pwnd.PrefixLength] {
+			fmt.Fprintf(w, "%s:42\r\n", pwned[pwnd.PrefixLength:])
+		}
+	}))
+	defer server.Close()
+
+	_, store := newTestStore()
+	env := &spass.Env{HAVEIBEENPWND_URL: server.URL}
+
+	for name, password := range map[string]string{"bank": "hunter2", "mail": "correct horse battery staple"} {
+		_, _, err := run(t, env, store, password+"\n", "insert", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, stderr, err := run(t, env, store, "", "audit", "pwnd", "--rate", "0")
+	if err != errAudit {
+		t.Errorf("expected the audit to fail, got %v", err)
+	}
+	if !strings.HasPrefix(stdout, "SECRET  PWND\nbank    42 times\nmail    no\n") {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if stderr != "1 of 2 passwords have been pwnd\n" {
+		t.Errorf("unexpected summary %q", stderr)
+	}
+
+	available = false
+
+	_, stderr, err = run(t, env, store, "", "audit", "pwnd", "--rate", "0")
+	if err != errAudit {
+		t.Errorf("expected the audit to fail, got %v", err)
+	}
+	if stderr != "2 of 2 passwords could not be checked\n" {
+		t.Errorf("expected only the failed lookups in the summary, got %q", stderr)
+	}
+}
+
+func TestAuditStrengthCommand(t *testing.T) {
+	crypto := &spass.TestCrypto{}
+	store := spass.NewMemoryStore(crypto, "test")
+	env := &spass.Env{}
+
+	_, _, err := run(t, env, store, "password1\n", "insert", "weak")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := run(t, env, store, "", "audit", "strength")
+	if err != errAudit {
+		t.Errorf("expected the audit to fail, got %v", err)
+	}
+	if stderr != "1 of 1 passwords are weak\n" {
+		t.Errorf("unexpected summary %q", stderr)
+	}
+
+	// Secrets that can not be decrypted are not weak.
+	crypto.Identities = []string{"other"}
+
+	_, stderr, err = run(t, env, store, "", "audit", "strength")
+	if err != errAudit {
+		t.Errorf("expected the audit to fail, got %v", err)
+	}
+	if stderr != "1 of 1 passwords could not be checked\n" {
+		t.Errorf("expected only the failed secrets in the summary, got %q", stderr)
+	}
+}
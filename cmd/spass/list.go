@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/romeovs/spass/pkg/spass"
+)
+
+// printTree prints the secrets as a tree, like pass does.
+func printTree(w io.Writer, title string, namespace string, secrets []spass.Secret) {
+	root := &node{}
+	for _, secret := range secrets {
+		name := secret.FullName()
+		if namespace != "" {
+			name = strings.TrimPrefix(name, namespace+"/")
+		}
+
+		n := root
+		parts := strings.Split(name, "/")
+		for i, part := range parts {
+			n = n.child(part, i < len(parts)-1)
+		}
+	}
+
+	fmt.Fprintln(w, title)
+	root.print(w, "")
+}
+
+// node is a secret or a namespace in the tree.
+type node struct {
+	name     string
+	dir      bool
+	children []*node
+}
+
+func (n *node) child(name string, dir bool) *node {
+	for _, c := range n.children {
+		if c.name == name && c.dir == dir {
+			return c
+		}
+	}
+
+	c := &node{
+		name: name,
+		dir:  dir,
+	}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *node) print(w io.Writer, prefix string) {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return n.children[i].name < n.children[j].name
+	})
+
+	for i, c := range n.children {
+		branch, indent := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		fmt.Fprintf(w, "%s%s%s\n", prefix, branch, c.name)
+		c.print(w, prefix+indent)
+	}
+}
+
+type jsonSecret struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	FullName  string `json:"fullname"`
+}
+
+// printJSON prints the secrets as a json array.
+func printJSON(w io.Writer, secrets []spass.Secret) error {
+	res := make([]*jsonSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		res = append(res, &jsonSecret{
+			Name:      secret.Name(),
+			Namespace: secret.Namespace(),
+			FullName:  secret.FullName(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
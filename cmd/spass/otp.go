@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+	"github.com/romeovs/spass/pkg/spass"
+)
+
+// isOTP checks if the line is an otp uri, either time or counter based.
+func isOTP(line string) bool {
+	return strings.HasPrefix(line, "otpauth://totp/") || strings.HasPrefix(line, "otpauth://hotp/")
+}
+
+// findOTP finds the otp uri in the body of a secret.
+// It returns the empty string if there is none.
+func findOTP(body string) string {
+	otpauth := ""
+	for _, line := range strings.Split(body, "\n") {
+		if isOTP(line) {
+			otpauth = line
+		}
+	}
+	return otpauth
+}
+
+var counterRegex = regexp.MustCompile(`([?&]counter=)[0-9]+`)
+
+// nextHOTP increments the counter of the hotp uri and generates the code for it.
+// Like pass-otp, the secret is updated with the new counter so it stays in sync.
+func nextHOTP(ctx context.Context, secret spass.Secret, otpauth string) (string, error) {
+	key, err := otp.NewKeyFromURL(otpauth)
+	if err != nil {
+		return "", fmt.Errorf("invalid otp set up in secret '%s'", secret.FullName())
+	}
+
+	u, err := url.Parse(otpauth)
+	if err != nil {
+		return "", fmt.Errorf("invalid otp set up in secret '%s'", secret.FullName())
+	}
+
+	counter, err := strconv.ParseUint(u.Query().Get("counter"), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid hotp counter in secret '%s'", secret.FullName())
+	}
+
+	counter++
+
+	code, err := hotp.GenerateCodeCustom(key.Secret(), counter, hotp.ValidateOpts{
+		Digits:    key.Digits(),
+		Algorithm: key.Algorithm(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %v", err)
+	}
+
+	body, err := secret.Body(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	next := counterRegex.ReplaceAllString(otpauth, "${1}"+strconv.FormatUint(counter, 10))
+
+	lines := strings.Split(body, "\n")
+	found := false
+	for i, line := range lines {
+		if line == otpauth {
+			lines[i] = next
+			found = true
+		}
+	}
+
+	if !found {
+		return "", errors.New("unreachable")
+	}
+
+	err = secret.Write(ctx, strings.Join(lines, "\n"))
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+var errOTPExists = errors.New("an otp secret is already stored for this secret, overwrite with --overwrite")
+
+// saveOTP adds the otp uri to the named secret, creating the secret if it does not exist yet.
+// An existing otp uri is only replaced when overwrite is set.
+func saveOTP(ctx context.Context, store spass.Store, name string, otpauth string, overwrite bool, dryRun bool) (spass.Secret, error) {
+	body := ""
+	secret, err := store.Secret(ctx, name)
+	if err == nil {
+		body, err = secret.Body(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		secret, err = store.NewSecret(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines := []string{}
+	written := false
+	for _, line := range strings.Split(body, "\n") {
+		if !isOTP(line) {
+			lines = append(lines, line)
+			continue
+		}
+
+		if !overwrite {
+			return nil, errOTPExists
+		}
+
+		if !written {
+			lines = append(lines, otpauth)
+			written = true
+		}
+	}
+
+	if !written {
+		// Keep the first line for the password and the trailing newline at the end.
+		end := len(lines)
+		for end > 1 && lines[end-1] == "" {
+			end--
+		}
+		lines = append(lines[:end:end], otpauth, "")
+	}
+
+	if dryRun {
+		return secret, nil
+	}
+
+	err = secret.Write(ctx, strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// otpName creates a secret name for an otp from its issuer and account name.
+func otpName(namespace string, issuer string, account string) string {
+	clean := func(part string) string {
+		return strings.TrimSpace(strings.ReplaceAll(part, "/", "-"))
+	}
+
+	parts := []string{}
+	if namespace != "" {
+		parts = append(parts, strings.Trim(namespace, "/"))
+	}
+	if clean(issuer) != "" {
+		parts = append(parts, clean(issuer))
+	}
+	if clean(account) != "" {
+		parts = append(parts, clean(account))
+	} else {
+		parts = append(parts, "unnamed")
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// totpCode generates the code of the totp key at the time, and the number of seconds it stays valid.
+func totpCode(key *otp.Key, now time.Time) (string, int64, error) {
+	period := int64(key.Period())
+	left := period - now.Unix()%period
+
+	code, err := totp.GenerateCodeCustom(key.Secret(), now, totp.ValidateOpts{
+		Period:    uint(period),
+		Digits:    key.Digits(),
+		Algorithm: key.Algorithm(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to generate code: %v", err)
+	}
+
+	return code, left, nil
+}
+
+// currentTOTP generates the current code of the totp key.
+// When wait is set and the code is about to expire, it waits for the next one.
+func currentTOTP(w io.Writer, key *otp.Key, wait bool) (string, int64, error) {
+	code, left, err := totpCode(key, time.Now())
+	if err != nil {
+		return "", 0, err
+	}
+
+	if wait && left < 3 {
+		fmt.Fprintln(w, "waiting for new token...")
+		time.Sleep(time.Duration(left+1) * time.Second)
+		return totpCode(key, time.Now())
+	}
+
+	return code, left, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kbinani/screenshot"
+	"github.com/makiuchi-d/gozxing"
+	multiqrcode "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/pquerna/otp"
+	"github.com/romeovs/spass/pkg/migration"
+)
+
+// scanScreen reads the qr codes that are shown on any of the displays.
+func scanScreen() ([]string, error) {
+	n := screenshot.NumActiveDisplays()
+	if n == 0 {
+		return nil, errors.New("no displays found, use --image to scan an image file")
+	}
+
+	res := []string{}
+	for i := 0; i < n; i++ {
+		img, err := screenshot.CaptureRect(screenshot.GetDisplayBounds(i))
+		if err != nil {
+			return nil, err
+		}
+
+		codes, _ := decodeQR(img)
+		res = appendUnique(res, codes...)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("No qr code found")
+	}
+
+	return res, nil
+}
+
+// scanImage reads the qr codes in the image file, or in stdin when the filename is "-".
+func scanImage(filename string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("could not open image '%s'", filename)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read image '%s', only png, jpeg and gif are supported", filename)
+	}
+
+	codes, err := decodeQR(img)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(codes) == 0 {
+		return nil, errors.New("No qr code found")
+	}
+
+	return codes, nil
+}
+
+// decodeQR finds all qr codes in the image.
+func decodeQR(img image.Image) ([]string, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+
+	results, err := multiqrcode.NewQRCodeMultiReader().DecodeMultipleWithoutHint(bmp)
+	if err == nil {
+		for _, result := range results {
+			res = appendUnique(res, result.String())
+		}
+	}
+
+	// The multi reader misses some codes the regular reader finds.
+	if len(res) == 0 {
+		result, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+		if err == nil {
+			res = appendUnique(res, result.String())
+		}
+	}
+
+	return res, nil
+}
+
+// chooseQR asks which of the qr codes to use when more than one was found.
+func chooseQR(codes []string, stdin io.Reader, w io.Writer) (string, error) {
+	if len(codes) == 1 {
+		return codes[0], nil
+	}
+
+	if stdin == nil {
+		return "", fmt.Errorf("found %d qr codes, only one is supported when reading from stdin", len(codes))
+	}
+
+	fmt.Fprintf(w, "Found %d qr codes:\n", len(codes))
+	for i, code := range codes {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, describeQR(code))
+	}
+	fmt.Fprintf(w, "Which one should be stored? [1-%d]: ", len(codes))
+
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.New("no qr code chosen")
+	}
+
+	i, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || i < 1 || i > len(codes) {
+		return "", fmt.Errorf("invalid choice '%s'", strings.TrimSpace(line))
+	}
+
+	return codes[i-1], nil
+}
+
+// describeQR describes the contents of a qr code without showing any secrets.
+func describeQR(code string) string {
+	if strings.HasPrefix(code, migration.Prefix) {
+		accounts, err := migration.Parse(code)
+		if err != nil {
+			return "invalid Google Authenticator export"
+		}
+		return fmt.Sprintf("Google Authenticator export with %d accounts", len(accounts))
+	}
+
+	if isOTP(code) {
+		key, err := otp.NewKeyFromURL(code)
+		if err != nil {
+			return "invalid otp"
+		}
+		return fmt.Sprintf("%s otp for %s (%s)", strings.ToUpper(key.Type()), key.AccountName(), key.Issuer())
+	}
+
+	return "not an otp"
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, x := range list {
+			if x == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
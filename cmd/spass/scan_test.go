@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	totpURI = "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	hotpURI = "otpauth://hotp/Bank:bob?secret=7L57Z7P674AACAQD&issuer=Bank&counter=5"
+)
+
+func TestScanImage(t *testing.T) {
+	codes, err := scanImage("testdata/otpauth.png", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 1 || codes[0] != totpURI {
+		t.Errorf("expected %q, got %q", totpURI, codes)
+	}
+
+	file, err := os.Open("testdata/otpauth.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	codes, err = scanImage("-", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 1 || codes[0] != totpURI {
+		t.Errorf("expected %q from stdin, got %q", totpURI, codes)
+	}
+
+	codes, err = scanImage("testdata/otpauth-two.png", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(codes)
+	if len(codes) != 2 || codes[0] != hotpURI || codes[1] != totpURI {
+		t.Errorf("expected both qr codes, got %q", codes)
+	}
+}
+
+func TestScanImageInvalid(t *testing.T) {
+	_, err := scanImage("testdata/missing.png", nil)
+	if err == nil || err.Error() != "could not open image 'testdata/missing.png'" {
+		t.Errorf("expected an error for a missing image, got %v", err)
+	}
+
+	_, err = scanImage("-", strings.NewReader("not an image"))
+	if err == nil || !strings.HasPrefix(err.Error(), "could not read image '-'") {
+		t.Errorf("expected an error for an invalid image, got %v", err)
+	}
+}
+
+func TestScanCommand(t *testing.T) {
+	env, store := newTestStore()
+
+	stdout, _, err := run(t, env, store, "", "scan", "--image", "testdata/otpauth.png", "web/example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "Issuer: Example\nAccount: alice@example.com\nsecret 'web/example' saved!\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if got := body(t, store, "web/example"); !strings.Contains(got, totpURI+"\n") {
+		t.Errorf("expected the otpauth uri to be stored, got %q", got)
+	}
+}
+
+func TestScanCommandChoose(t *testing.T) {
+	env, store := newTestStore()
+
+	codes, err := scanImage("testdata/otpauth-two.png", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	choice := 1
+	for i, code := range codes {
+		if code == hotpURI {
+			choice = i + 1
+		}
+	}
+
+	stdin := strconv.Itoa(choice) + "\n"
+	stdout, stderr, err := run(t, env, store, stdin, "scan", "--image", "testdata/otpauth-two.png", "bank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, "Which one should be stored? [1-2]: ") {
+		t.Errorf("expected to be asked which qr code to store, got %q", stderr)
+	}
+	if !strings.Contains(stdout, "secret 'bank' saved!\n") {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if got := body(t, store, "bank"); !strings.Contains(got, hotpURI+"\n") {
+		t.Errorf("expected the chosen otpauth uri to be stored, got %q", got)
+	}
+
+	image, err := os.ReadFile("testdata/otpauth-two.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stdin is used for the image, so there is no way to choose.
+	_, _, err = run(t, env, store, string(image), "scan", "--image", "-", "other")
+	if err == nil || err.Error() != "found 2 qr codes, only one is supported when reading from stdin" {
+		t.Errorf("expected an error when choosing is not possible, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/romeovs/spass/pkg/spass"
+	"golang.org/x/term"
+)
+
+// watch shows a live dashboard of the time based otps in the namespace.
+func watch(ctx context.Context, store spass.Store, namespace string, w io.Writer, errw io.Writer) error {
+	entries, err := loadOTPs(ctx, store, namespace, errw)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return errors.New("no otp secrets found")
+	}
+
+	return watchOTPs(ctx, w, entries)
+}
+
+// otpEntry is a row in the otp dashboard.
+type otpEntry struct {
+	name string
+	key  *otp.Key
+
+	// The current code, it is only regenerated at the end of its period.
+	code    string
+	counter int64
+}
+
+// loadOTPs decrypts all secrets in the namespace once and collects their time based otps.
+// Counter based otps are skipped, because showing them would increment their counter.
+func loadOTPs(ctx context.Context, store spass.Store, namespace string, errw io.Writer) ([]*otpEntry, error) {
+	secrets, err := store.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*otpEntry{}
+	for _, secret := range secrets {
+		body, err := secret.Body(ctx)
+		if err != nil {
+			fmt.Fprintf(errw, "skipping secret '%s': %s\n", secret.FullName(), err)
+			continue
+		}
+
+		otpauth := findOTP(body)
+		if !strings.HasPrefix(otpauth, "otpauth://totp/") {
+			continue
+		}
+
+		key, err := otp.NewKeyFromURL(otpauth)
+		if err != nil {
+			fmt.Fprintf(errw, "skipping secret '%s': invalid otp\n", secret.FullName())
+			continue
+		}
+
+		entries = append(entries, &otpEntry{
+			name:    secret.FullName(),
+			key:     key,
+			counter: -1,
+		})
+	}
+
+	return entries, nil
+}
+
+// renderOTPs writes a table with the codes at the time and how long they are still valid.
+func renderOTPs(w io.Writer, entries []*otpEntry, now time.Time) error {
+	width := 0
+	for _, entry := range entries {
+		if len(entry.name) > width {
+			width = len(entry.name)
+		}
+	}
+
+	for _, entry := range entries {
+		period := int64(entry.key.Period())
+		counter := now.Unix() / period
+
+		if counter != entry.counter {
+			code, _, err := totpCode(entry.key, now)
+			if err != nil {
+				return err
+			}
+			entry.code = code
+			entry.counter = counter
+		}
+
+		left := period - now.Unix()%period
+		fmt.Fprintf(w, "%-*s  %-8s  %s %2ds\n", width, entry.name, entry.code, bar(left, period, 20), left)
+	}
+
+	return nil
+}
+
+// watchOTPs keeps rendering the otp table until the context is done.
+// When w is not a terminal, the table is rendered once.
+func watchOTPs(ctx context.Context, w io.Writer, entries []*otpEntry) error {
+	file, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return renderOTPs(w, entries, time.Now())
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Hide the cursor while rendering and show it again when done.
+	fmt.Fprint(w, "\033[?25l")
+	defer fmt.Fprint(w, "\033[?25h")
+
+	for {
+		fmt.Fprint(w, "\033[H\033[2J")
+		err := renderOTPs(w, entries, time.Now())
+		if err != nil {
+			return err
+		}
+
+		// Wake up right after the next second starts.
+		next := time.Until(time.Now().Truncate(time.Second).Add(time.Second))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(next):
+		}
+	}
+}
+
+// bar draws a bar that is filled for the part of total that is left.
+func bar(left int64, total int64, width int) string {
+	filled := int(left * int64(width) / total)
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
@@ -0,0 +1,201 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"golang.design/x/clipboard"
+)
+
+// errNoRead is returned by backends that can only write to the clipboard.
+var errNoRead = errors.New("the clipboard can not be read")
+
+// backend reads and writes the clipboard.
+type backend interface {
+	// Read the text on the clipboard.
+	Read() ([]byte, error)
+
+	// Write the text to the clipboard.
+	// The returned channel is closed when the text is replaced, if the backend can tell.
+	Write(text []byte) (<-chan struct{}, error)
+}
+
+var (
+	selected = "auto"
+
+	once    sync.Once
+	current backend
+	openErr error
+)
+
+// Use selects the clipboard backend by name.
+// It is one of auto, native, wl-copy, xclip, xsel, osc52 or file:PATH.
+func Use(backend string) {
+	if backend != "" {
+		selected = backend
+	}
+}
+
+// get initializes the selected backend the first time it is needed.
+func get() (backend, error) {
+	once.Do(func() {
+		current, openErr = open(selected)
+	})
+	return current, openErr
+}
+
+func open(name string) (backend, error) {
+	switch {
+	case name == "auto":
+		return auto()
+	case name == "native":
+		return newNative()
+	case name == "wl-copy":
+		return newCommand("wl-copy", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"})
+	case name == "xclip":
+		return newCommand("xclip", []string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"})
+	case name == "xsel":
+		return newCommand("xsel", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"})
+	case name == "osc52":
+		return newOSC52()
+	case strings.HasPrefix(name, "file:"):
+		return &file{path: strings.TrimPrefix(name, "file:")}, nil
+	default:
+		return nil, fmt.Errorf("unknown clipboard '%s'", name)
+	}
+}
+
+// auto uses the first clipboard that works in this session.
+func auto() (backend, error) {
+	candidates := []string{}
+
+	// The native clipboard only supports X11 on linux.
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append(candidates, "wl-copy")
+	}
+
+	candidates = append(candidates, "native")
+
+	if os.Getenv("DISPLAY") != "" {
+		candidates = append(candidates, "xclip", "xsel")
+	}
+
+	// Works through ssh and tmux, as long as the terminal supports it.
+	candidates = append(candidates, "osc52")
+
+	for _, name := range candidates {
+		b, err := open(name)
+		if err == nil {
+			// The process that clears the clipboard should use the same one.
+			selected = name
+			return b, nil
+		}
+	}
+
+	return nil, errors.New("no clipboard available, set SPASS_CLIPBOARD to choose one")
+}
+
+// native uses the clipboard of the operating system.
+type native struct{}
+
+func newNative() (*native, error) {
+	err := clipboard.Init()
+	if err != nil {
+		return nil, fmt.Errorf("could not use the clipboard: %s", err)
+	}
+	return &native{}, nil
+}
+
+func (n *native) Read() ([]byte, error) {
+	return clipboard.Read(clipboard.FmtText), nil
+}
+
+func (n *native) Write(text []byte) (<-chan struct{}, error) {
+	changed := clipboard.Write(clipboard.FmtText, text)
+	if changed == nil {
+		return nil, errors.New("could not write to the clipboard")
+	}
+	return changed, nil
+}
+
+// command uses external programs like xclip to copy and paste.
+type command struct {
+	name  string
+	copy  []string
+	paste []string
+}
+
+func newCommand(name string, in []string, out []string) (*command, error) {
+	_, err := exec.LookPath(in[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not find '%s'", in[0])
+	}
+
+	return &command{
+		name:  name,
+		copy:  in,
+		paste: out,
+	}, nil
+}
+
+func (c *command) Read() ([]byte, error) {
+	buf, err := exec.Command(c.paste[0], c.paste[1:]...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the clipboard with %s", c.name)
+	}
+	return buf, nil
+}
+
+func (c *command) Write(text []byte) (<-chan struct{}, error) {
+	// These programs keep running in the background to serve the clipboard,
+	// so stdout and stderr are not connected, otherwise we would wait for them.
+	cmd := exec.Command(c.copy[0], c.copy[1:]...)
+	cmd.Stdin = bytes.NewReader(text)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("could not write to the clipboard with %s", c.name)
+	}
+	return nil, nil
+}
+
+// file writes the clipboard to a file or a named pipe, which is useful for testing.
+type file struct {
+	path string
+}
+
+func (f *file) Read() ([]byte, error) {
+	// Reading from a named pipe would take the text away from its reader.
+	info, err := os.Stat(f.path)
+	if err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return nil, errNoRead
+	}
+
+	buf, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read clipboard file '%s'", f.path)
+	}
+	return buf, nil
+}
+
+func (f *file) Write(text []byte) (<-chan struct{}, error) {
+	out, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open clipboard file '%s'", f.path)
+	}
+	defer out.Close()
+
+	_, err = out.Write(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not write clipboard file '%s'", f.path)
+	}
+	return nil, nil
+}
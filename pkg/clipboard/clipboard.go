@@ -1,14 +1,271 @@
+// Package clipboard copies secrets to the clipboard and clears them again.
 package clipboard
 
-import "golang.design/x/clipboard"
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
 
-func init() {
-	err := clipboard.Init()
+// clearEnv is set when spass is started to clear the clipboard.
+const clearEnv = "SPASS_CLIPBOARD_CLEAR"
+
+// clearer is the command Copy starts to clear the clipboard.
+var clearer []string
+
+// UseClearer sets the command that Copy starts to clear the clipboard.
+// The command is started with SPASS_CLIPBOARD_CLEAR set, and should call Clear
+// when Clearing reports true, before doing anything else. spass starts a copy
+// of itself, programs that embed this package have to do the same.
+func UseClearer(command ...string) {
+	clearer = command
+}
+
+// Write writes the text to the clipboard.
+func Write(text string) error {
+	b, err := get()
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	_, err = b.Write([]byte(text))
+	return err
 }
 
-func Write(text string) {
-	clipboard.Write(clipboard.FmtText, []byte(text))
+// Copy writes the text to the clipboard and clears it again after the timeout, like pass does.
+// When the timeout is 0, the clipboard is not cleared.
+//
+// The text is written by the detached command set with UseClearer, so the clipboard
+// is still cleared after spass exits. The previous contents of the clipboard are only
+// restored if the clipboard still holds the text by then.
+func Copy(text string, timeout time.Duration) error {
+	if len(clearer) == 0 {
+		return errors.New("no command set to clear the clipboard, see clipboard.UseClearer")
+	}
+
+	b, err := get()
+	if err != nil {
+		return err
+	}
+
+	// Only one process should clear the clipboard, otherwise it would
+	// restore the text we copied earlier.
+	stopClearer()
+
+	stdin, input, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+
+	output, stdout, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	cmd := exec.Command(clearer[0], clearer[1:]...)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%d", clearEnv, int(timeout.Seconds())),
+		fmt.Sprintf("SPASS_CLIPBOARD=%s", selected),
+	)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	detach(cmd)
+
+	// Writing to the terminal needs the terminal, which the detached process does not have.
+	if o, ok := b.(*osc52); ok {
+		cmd.ExtraFiles = []*os.File{o.tty}
+		cmd.Env = append(cmd.Env, ttyEnv+"=1")
+	}
+
+	err = cmd.Start()
+	stdout.Close()
+	if err != nil {
+		input.Close()
+		return fmt.Errorf("could not start process to clear the clipboard: %s", err)
+	}
+
+	// The text is passed through stdin, so it does not show up in the process list.
+	io.WriteString(input, text)
+	input.Close()
+
+	// Wait for the text to be copied, so the errors are not lost.
+	line, _ := bufio.NewReader(output).ReadString('\n')
+	cmd.Process.Release()
+
+	line = strings.TrimSpace(line)
+	if line != "ok" {
+		if line == "" {
+			return errors.New("could not copy to the clipboard")
+		}
+		return errors.New(line)
+	}
+
+	return nil
+}
+
+// Clearing reports if this process was started by Copy to clear the clipboard.
+// The process should call Clear and exit.
+func Clearing() bool {
+	return os.Getenv(clearEnv) != ""
+}
+
+// Clear runs in the clearer command started by Copy.
+// It copies the text from stdin and restores the previous contents of the clipboard
+// after the timeout, unless the clipboard was changed in the meantime.
+func Clear() error {
+	// Errors are reported to Copy, which is waiting for the text to be copied.
+	fail := func(err error) error {
+		fmt.Fprintln(os.Stdout, err)
+		return err
+	}
+
+	seconds, err := strconv.Atoi(os.Getenv(clearEnv))
+	if err != nil {
+		return fail(fmt.Errorf("invalid timeout '%s'", os.Getenv(clearEnv)))
+	}
+
+	text, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fail(err)
+	}
+
+	b, err := get()
+	if err != nil {
+		return fail(err)
+	}
+
+	// Some clipboards can not be read, those are always cleared.
+	previous, readErr := b.Read()
+	if readErr != nil {
+		previous = nil
+	}
+
+	changed, err := b.Write(text)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Without a timeout, only keep the text around for the platforms that need it.
+	if seconds == 0 {
+		fmt.Fprintln(os.Stdout, "ok")
+		os.Stdout.Close()
+
+		if changed != nil {
+			<-changed
+		}
+		return nil
+	}
+
+	// Stopping early is used when the clipboard is copied to again, which can
+	// happen as soon as the pid file is written.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	err = os.MkdirAll(runtimeDir(), 0700)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = os.WriteFile(pidFile(), []byte(strconv.Itoa(os.Getpid())), 0600)
+	if err != nil {
+		return fail(err)
+	}
+	defer removePidFile()
+
+	fmt.Fprintln(os.Stdout, "ok")
+	os.Stdout.Close()
+
+	select {
+	case <-changed:
+		return nil
+	case <-stop:
+	case <-time.After(time.Duration(seconds) * time.Second):
+	}
+
+	if readErr == nil {
+		now, err := b.Read()
+		if err != nil || !bytes.Equal(now, text) {
+			return nil
+		}
+	}
+
+	restored, err := b.Write(previous)
+	removePidFile()
+	if err != nil {
+		return err
+	}
+
+	// On some platforms the contents are lost when the process that copied them exits,
+	// so keep them around until something else is copied.
+	if len(previous) > 0 && restored != nil {
+		<-restored
+	}
+
+	return nil
+}
+
+// stopClearer stops the process that is waiting to clear the clipboard, if any.
+// It waits for the process to restore the clipboard.
+func stopClearer() {
+	buf, err := os.ReadFile(pidFile())
+	if err != nil {
+		return
+	}
+
+	pid, err := strconv.Atoi(string(buf))
+	if err != nil {
+		return
+	}
+
+	// The pid might have been reused by another process since.
+	if !isClearer(pid) || !stop(pid) {
+		os.Remove(pidFile())
+		return
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err := os.Stat(pidFile()); err != nil {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// pidFile is where the process that clears the clipboard keeps its pid.
+func pidFile() string {
+	return filepath.Join(runtimeDir(), "clipboard.pid")
+}
+
+// runtimeDir is a directory for the files of spass that only the user can access,
+// unlike the shared temporary directory.
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "spass")
+	}
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "spass")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("spass-%d", os.Getuid()))
+}
+
+// removePidFile removes the pid file, when it belongs to this process.
+func removePidFile() {
+	buf, err := os.ReadFile(pidFile())
+	if err == nil && string(buf) == strconv.Itoa(os.Getpid()) {
+		os.Remove(pidFile())
+	}
 }
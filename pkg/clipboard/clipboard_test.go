@@ -0,0 +1,228 @@
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// clipboardFile is the file the tests use as clipboard.
+var clipboardFile string
+
+func TestMain(m *testing.M) {
+	// Copy starts the test binary to clear the clipboard.
+	if Clearing() {
+		Use(os.Getenv("SPASS_CLIPBOARD"))
+		if err := Clear(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	dir, err := os.MkdirTemp("", "spass-clipboard")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Keep the pid file out of the real runtime directory.
+	os.Setenv("XDG_RUNTIME_DIR", dir)
+
+	clipboardFile = filepath.Join(dir, "clipboard")
+	Use("file:" + clipboardFile)
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	UseClearer(exe)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func setClipboard(t *testing.T, text string) {
+	t.Helper()
+
+	err := os.WriteFile(clipboardFile, []byte(text), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForClipboard waits until the clipboard holds the text.
+func waitForClipboard(t *testing.T, text string, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		buf, _ := os.ReadFile(clipboardFile)
+		if string(buf) == text {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the clipboard to hold %q, got %q", text, buf)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestCopyClears(t *testing.T) {
+	setClipboard(t, "previous")
+
+	err := Copy("hunter2", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForClipboard(t, "hunter2", 0)
+	if _, err := os.Stat(pidFile()); err != nil {
+		t.Errorf("expected a pid file: %s", err)
+	}
+
+	waitForClipboard(t, "previous", 5*time.Second)
+	waitForPidFile(t)
+}
+
+func TestCopyWithoutClearer(t *testing.T) {
+	setClipboard(t, "previous")
+
+	command := clearer
+	UseClearer()
+	defer UseClearer(command...)
+
+	err := Copy("hunter2", time.Second)
+	if err == nil {
+		t.Error("expected an error without a clearer")
+	}
+
+	buf, _ := os.ReadFile(clipboardFile)
+	if string(buf) != "previous" {
+		t.Errorf("expected the clipboard to be left alone, got %q", buf)
+	}
+}
+
+func TestCopyKeepsChangedClipboard(t *testing.T) {
+	setClipboard(t, "previous")
+
+	err := Copy("hunter2", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setClipboard(t, "copied by someone else")
+	waitForPidFile(t)
+
+	buf, _ := os.ReadFile(clipboardFile)
+	if string(buf) != "copied by someone else" {
+		t.Errorf("expected the clipboard to be left alone, got %q", buf)
+	}
+}
+
+func TestCopyStopsPreviousClearer(t *testing.T) {
+	setClipboard(t, "previous")
+
+	err := Copy("hunter2", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first process restores the previous text before the second one copies.
+	err = Copy("correct horse", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForClipboard(t, "correct horse", 0)
+	waitForClipboard(t, "previous", 5*time.Second)
+	waitForPidFile(t)
+}
+
+func TestIsClearer(t *testing.T) {
+	if isClearer(os.Getpid()) {
+		t.Error("the test itself is not clearing the clipboard")
+	}
+
+	// A clearer waits for the text on stdin.
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), clearEnv+"=60", "SPASS_CLIPBOARD=file:"+clipboardFile)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		stdin.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	if !isClearer(cmd.Process.Pid) {
+		t.Error("expected the process to be a clearer")
+	}
+}
+
+func TestStopClearerOtherProcess(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not installed")
+	}
+
+	other := exec.Command(sleep, "60")
+	err = other.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		other.Process.Kill()
+		other.Wait()
+	}()
+
+	// A stale pid file that points to a process that is not a clearer.
+	err = os.MkdirAll(runtimeDir(), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(pidFile(), []byte(strconv.Itoa(other.Process.Pid)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopClearer()
+
+	if _, err := os.Stat(pidFile()); !os.IsNotExist(err) {
+		t.Error("expected the stale pid file to be removed")
+	}
+	if isClearer(other.Process.Pid) {
+		t.Error("the other process is not a clearer")
+	}
+
+	// It should not have been stopped.
+	if err := other.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("the other process was stopped: %s", err)
+	}
+}
+
+// waitForPidFile waits for the clearer to exit.
+func waitForPidFile(t *testing.T) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(pidFile()); os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("expected the clearer to exit")
+}
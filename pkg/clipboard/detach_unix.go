@@ -0,0 +1,48 @@
+//go:build !windows
+
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// detach starts the command in its own session, so it keeps running after spass
+// and the terminal exit.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+	}
+}
+
+// stop asks the process to stop, it reports if the process was running.
+func stop(pid int) bool {
+	return syscall.Kill(pid, syscall.SIGTERM) == nil
+}
+
+// isClearer reports if the process was started by Copy to clear the clipboard.
+func isClearer(pid int) bool {
+	// Where there is a /proc, the environment of our own processes can be read.
+	env, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err == nil {
+		for _, v := range bytes.Split(env, []byte{0}) {
+			if bytes.HasPrefix(v, []byte(clearEnv+"=")) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if _, err := os.Stat("/proc/self"); err == nil {
+		return false
+	}
+
+	// Otherwise compare the command.
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "command=").Output()
+	return err == nil && strings.TrimSpace(string(out)) == strings.Join(clearer, " ")
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// detach starts the command without a console, so it keeps running after spass exits.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: createNewProcessGroup | detachedProcess,
+	}
+}
+
+// stop stops the process, it reports if the process was running.
+// Windows has no way to ask a process to stop, so it is killed without restoring the clipboard.
+func stop(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Kill() == nil
+}
+
+// isClearer reports if the process runs the clearer executable, which is the
+// best we can tell on windows.
+func isClearer(pid int) bool {
+	if len(clearer) == 0 {
+		return false
+	}
+
+	out, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(string(out)), strings.ToLower(fmt.Sprintf("\"%s\"", filepath.Base(clearer[0]))))
+}
@@ -0,0 +1,57 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ttyEnv is set when the terminal is passed to the process that clears the clipboard.
+const ttyEnv = "SPASS_CLIPBOARD_TTY"
+
+// osc52 copies by sending an escape sequence to the terminal, which also
+// works through ssh and tmux. Terminals do not allow reading the clipboard.
+type osc52 struct {
+	tty *os.File
+}
+
+func newOSC52() (*osc52, error) {
+	// The process that clears the clipboard has no terminal of its own.
+	if os.Getenv(ttyEnv) != "" {
+		return &osc52{
+			tty: os.NewFile(3, "/dev/tty"),
+		}, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, errors.New("no terminal to copy to")
+	}
+
+	return &osc52{
+		tty: tty,
+	}, nil
+}
+
+func (o *osc52) Read() ([]byte, error) {
+	return nil, errNoRead
+}
+
+func (o *osc52) Write(text []byte) (<-chan struct{}, error) {
+	seq := fmt.Sprintf("\033]52;c;%s\a", base64.StdEncoding.EncodeToString(text))
+
+	// Multiplexers only pass the sequence on when it is wrapped.
+	if os.Getenv("TMUX") != "" {
+		seq = "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+	} else if os.Getenv("STY") != "" {
+		seq = "\033P" + seq + "\033\\"
+	}
+
+	_, err := o.tty.WriteString(seq)
+	if err != nil {
+		return nil, errors.New("could not write to the terminal")
+	}
+	return nil, nil
+}
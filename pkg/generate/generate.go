@@ -5,45 +5,41 @@ import (
 	"math/big"
 )
 
-type Generator struct {
-	LowerCase bool
-	NoDigits  bool
-	NoSymbols bool
-}
-
 const (
 	lower  = "abcdefghijklmnopqrstuvwxyz"
 	upper  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	digit  = "0123456789"
 	symbol = "~!@#$%^&*()_+`-={}|[]\\:\"<>?,./"
-)
-
-func (g *Generator) Generate(size int) (string, error) {
-	alphabet := lower
 
-	if !g.LowerCase {
-		alphabet += upper
-	}
+	// Characters that are easily confused with each other.
+	ambiguous = "0O1lI|"
+)
 
-	if !g.NoDigits {
-		alphabet += digit
-	}
+// Generator generates passwords from all characters of the classes it uses.
+//
+// Deprecated: use a Policy, which can also guarantee characters of every class.
+type Generator struct {
+	LowerCase bool
+	NoDigits  bool
+	NoSymbols bool
+}
 
-	if !g.NoSymbols {
-		alphabet += symbol
+// Generate generates a password of size characters.
+func (g *Generator) Generate(size int) (string, error) {
+	policy := &Policy{
+		Length:    size,
+		NoUpper:   g.LowerCase,
+		NoDigits:  g.NoDigits,
+		NoSymbols: g.NoSymbols,
 	}
+	return policy.Generate()
+}
 
-	result := ""
-	max := big.NewInt(int64(len(alphabet)))
-
-	for i := 0; i < size; i++ {
-		n, err := rand.Int(rand.Reader, max)
-		if err != nil {
-			return "", err
-		}
-
-		result += string(alphabet[n.Int64()])
+// random returns a uniformly random number in [0, max).
+func random(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
 	}
-
-	return result, nil
+	return int(n.Int64()), nil
 }
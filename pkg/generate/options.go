@@ -0,0 +1,194 @@
+package generate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Options configure how to generate a password or a passphrase.
+//
+// They can be read from policy files, with one setting per line:
+//
+//	# Our bank does not allow symbols
+//	length: 12
+//	no-symbols: true
+//
+// The settings have the same names as the flags of spass generate.
+// Values are trimmed, quote them to keep their spaces:
+//
+//	separator: " "
+type Options struct {
+	Policy     *Policy
+	Passphrase *Passphrase
+
+	// The file with the words for the passphrase, defaults to the EFF large wordlist.
+	Wordlist string
+}
+
+// DefaultOptions generates passwords with the default policy.
+func DefaultOptions() *Options {
+	return &Options{
+		Policy: DefaultPolicy(),
+		Passphrase: &Passphrase{
+			Separator: "-",
+		},
+	}
+}
+
+// Set changes a setting by name.
+func (o *Options) Set(key string, value string) error {
+	var err error
+	switch key {
+	case "length":
+		o.Policy.Length, err = strconv.Atoi(value)
+	case "lowercase":
+		o.Policy.NoUpper, err = strconv.ParseBool(value)
+	case "no-numbers":
+		o.Policy.NoDigits, err = strconv.ParseBool(value)
+	case "no-symbols":
+		o.Policy.NoSymbols, err = strconv.ParseBool(value)
+	case "min-lower":
+		o.Policy.MinLower, err = strconv.Atoi(value)
+	case "min-upper":
+		o.Policy.MinUpper, err = strconv.Atoi(value)
+	case "min-digits":
+		o.Policy.MinDigits, err = strconv.Atoi(value)
+	case "min-symbols":
+		o.Policy.MinSymbols, err = strconv.Atoi(value)
+	case "allowed":
+		o.Policy.Allowed = value
+	case "forbidden":
+		o.Policy.Forbidden = value
+	case "no-ambiguous":
+		o.Policy.NoAmbiguous, err = strconv.ParseBool(value)
+	case "max-repeat":
+		o.Policy.MaxRepeat, err = strconv.Atoi(value)
+	case "words":
+		o.Passphrase.Words, err = strconv.Atoi(value)
+	case "wordlist":
+		o.Wordlist = value
+	case "separator":
+		o.Passphrase.Separator = value
+	case "capitalize":
+		o.Passphrase.Capitalize, err = strconv.ParseBool(value)
+	case "digit":
+		o.Passphrase.Digit, err = strconv.ParseBool(value)
+	case "symbol":
+		o.Passphrase.Symbol, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown setting '%s'", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value '%s' for setting '%s'", value, key)
+	}
+
+	return nil
+}
+
+// ReadFile reads the settings in a policy file.
+func (o *Options) ReadFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("could not read policy '%s'", filename)
+	}
+
+	for i, line := range strings.Split(string(buf), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("invalid line %d in policy '%s'", i+1, filename)
+		}
+
+		// Values can be quoted to keep their spaces, like a separator of a single space.
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "\"") {
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("invalid quoted value on line %d in policy '%s'", i+1, filename)
+			}
+		}
+
+		err = o.Set(strings.TrimSpace(key), value)
+		if err != nil {
+			return fmt.Errorf("%s in policy '%s'", err, filename)
+		}
+	}
+
+	// Wordlists are relative to the policy.
+	if o.Wordlist != "" && !filepath.IsAbs(o.Wordlist) {
+		o.Wordlist = filepath.Join(filepath.Dir(filename), o.Wordlist)
+	}
+
+	return nil
+}
+
+// Generate generates a passphrase if the number of words is set, a password otherwise.
+func (o *Options) Generate() (string, error) {
+	if o.Passphrase.Words == 0 {
+		return o.Policy.Generate()
+	}
+
+	if o.Wordlist != "" {
+		words, err := ReadWordlist(o.Wordlist)
+		if err != nil {
+			return "", err
+		}
+		o.Passphrase.Wordlist = words
+	}
+
+	return o.Passphrase.Generate()
+}
+
+// String formats the options like a policy file.
+func (o *Options) String() string {
+	lines := []string{}
+	add := func(key string, value interface{}) {
+		lines = append(lines, fmt.Sprintf("%s: %v", key, value))
+	}
+
+	if o.Passphrase.Words > 0 {
+		add("words", o.Passphrase.Words)
+		if o.Wordlist != "" {
+			add("wordlist", quote(o.Wordlist))
+		}
+		add("separator", quote(o.Passphrase.Separator))
+		add("capitalize", o.Passphrase.Capitalize)
+		add("digit", o.Passphrase.Digit)
+		add("symbol", o.Passphrase.Symbol)
+	} else {
+		add("length", o.Policy.Length)
+		add("lowercase", o.Policy.NoUpper)
+		add("no-numbers", o.Policy.NoDigits)
+		add("no-symbols", o.Policy.NoSymbols)
+		add("min-lower", o.Policy.MinLower)
+		add("min-upper", o.Policy.MinUpper)
+		add("min-digits", o.Policy.MinDigits)
+		add("min-symbols", o.Policy.MinSymbols)
+		if o.Policy.Allowed != "" {
+			add("allowed", quote(o.Policy.Allowed))
+		}
+		if o.Policy.Forbidden != "" {
+			add("forbidden", quote(o.Policy.Forbidden))
+		}
+		add("no-ambiguous", o.Policy.NoAmbiguous)
+		add("max-repeat", o.Policy.MaxRepeat)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// quote quotes values that would change when they are read from a policy file.
+func quote(value string) string {
+	if value != strings.TrimSpace(value) || strings.HasPrefix(value, "\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,231 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    func(o *Options)
+		err     string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    func(o *Options) {},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# The bank does not accept symbols\n\nlength: 12\n  # indented comment\nno-symbols: true\n",
+			want: func(o *Options) {
+				o.Policy.Length = 12
+				o.Policy.NoSymbols = true
+			},
+		},
+		{
+			name:    "windows line endings",
+			content: "length: 24\r\nallowed: abc\r\n",
+			want: func(o *Options) {
+				o.Policy.Length = 24
+				o.Policy.Allowed = "abc"
+			},
+		},
+		{
+			name:    "spaces around values",
+			content: "length:  12\nno-symbols: true \nmin-digits:\t3\t\n",
+			want: func(o *Options) {
+				o.Policy.Length = 12
+				o.Policy.NoSymbols = true
+				o.Policy.MinDigits = 3
+			},
+		},
+		{
+			name:    "quoted values",
+			content: "words: 5\nseparator: \" \"\ncapitalize: true\nallowed: \"a#b\"\n",
+			want: func(o *Options) {
+				o.Passphrase.Words = 5
+				o.Passphrase.Separator = " "
+				o.Passphrase.Capitalize = true
+				o.Policy.Allowed = "a#b"
+			},
+		},
+		{
+			name:    "relative wordlist",
+			content: "words: 4\nwordlist: words.txt\n",
+			want: func(o *Options) {
+				o.Passphrase.Words = 4
+				o.Wordlist = filepath.Join(dir, "words.txt")
+			},
+		},
+		{
+			name:    "absolute wordlist",
+			content: "wordlist: /usr/share/dict/words\n",
+			want: func(o *Options) {
+				o.Wordlist = "/usr/share/dict/words"
+			},
+		},
+		{
+			name:    "unknown setting",
+			content: "length: 12\ncolor: blue\n",
+			err:     "unknown setting 'color' in policy",
+		},
+		{
+			name:    "invalid value",
+			content: "length: long\n",
+			err:     "invalid value 'long' for setting 'length' in policy",
+		},
+		{
+			name:    "invalid quoted value",
+			content: "separator: \" \n",
+			err:     "invalid quoted value on line 1 in policy",
+		},
+		{
+			name:    "missing colon",
+			content: "length 12\n",
+			err:     "invalid line 1 in policy",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(dir, ".spass-policy")
+			err := os.WriteFile(filename, []byte(test.content), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			options := DefaultOptions()
+			err = options.ReadFile(filename)
+			if test.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Errorf("expected an error starting with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := DefaultOptions()
+			test.want(want)
+			if !reflect.DeepEqual(options, want) {
+				t.Errorf("got %s, want %s", options, want)
+			}
+		})
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	err := DefaultOptions().ReadFile(filepath.Join(t.TempDir(), "missing"))
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  func(o *Options)
+	}{
+		{"length", "30", func(o *Options) { o.Policy.Length = 30 }},
+		{"lowercase", "true", func(o *Options) { o.Policy.NoUpper = true }},
+		{"no-numbers", "true", func(o *Options) { o.Policy.NoDigits = true }},
+		{"no-symbols", "1", func(o *Options) { o.Policy.NoSymbols = true }},
+		{"min-lower", "2", func(o *Options) { o.Policy.MinLower = 2 }},
+		{"min-upper", "3", func(o *Options) { o.Policy.MinUpper = 3 }},
+		{"min-digits", "0", func(o *Options) { o.Policy.MinDigits = 0 }},
+		{"min-symbols", "4", func(o *Options) { o.Policy.MinSymbols = 4 }},
+		{"allowed", "abc123", func(o *Options) { o.Policy.Allowed = "abc123" }},
+		{"forbidden", "\"'", func(o *Options) { o.Policy.Forbidden = "\"'" }},
+		{"no-ambiguous", "true", func(o *Options) { o.Policy.NoAmbiguous = true }},
+		{"max-repeat", "2", func(o *Options) { o.Policy.MaxRepeat = 2 }},
+		{"words", "6", func(o *Options) { o.Passphrase.Words = 6 }},
+		{"wordlist", "words.txt", func(o *Options) { o.Wordlist = "words.txt" }},
+		{"separator", ".", func(o *Options) { o.Passphrase.Separator = "." }},
+		{"capitalize", "true", func(o *Options) { o.Passphrase.Capitalize = true }},
+		{"digit", "true", func(o *Options) { o.Passphrase.Digit = true }},
+		{"symbol", "true", func(o *Options) { o.Passphrase.Symbol = true }},
+	}
+
+	for _, test := range tests {
+		options := DefaultOptions()
+		err := options.Set(test.key, test.value)
+		if err != nil {
+			t.Errorf("Set(%q, %q): %s", test.key, test.value, err)
+			continue
+		}
+
+		want := DefaultOptions()
+		test.want(want)
+		if !reflect.DeepEqual(options, want) {
+			t.Errorf("Set(%q, %q) = %s, want %s", test.key, test.value, options, want)
+		}
+	}
+}
+
+func TestSetInvalid(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"length", ""},
+		{"length", "12.5"},
+		{"lowercase", "yes please"},
+		{"max-repeat", "two"},
+		{"words", "many"},
+		{"colour", "blue"},
+	}
+
+	for _, test := range tests {
+		err := DefaultOptions().Set(test.key, test.value)
+		if err == nil {
+			t.Errorf("Set(%q, %q): expected an error", test.key, test.value)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, content := range []string{
+		"length: 12\nno-symbols: true\nallowed: abcdef123\nmax-repeat: 2\n",
+		"words: 5\nseparator: .\ncapitalize: true\ndigit: true\n",
+		"words: 5\nseparator: \" \"\n",
+		"length: 12\nallowed: \"\\\"ab \"\n",
+	} {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, ".spass-policy")
+		err := os.WriteFile(filename, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		options := DefaultOptions()
+		err = options.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filename, []byte(options.String()), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again := DefaultOptions()
+		err = again.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if options.String() != again.String() {
+			t.Errorf("policy changed after writing it:\n%s\n%s", options, again)
+		}
+	}
+}
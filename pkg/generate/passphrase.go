@@ -0,0 +1,158 @@
+package generate
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// The EFF large wordlist, from https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt
+//
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+// Passphrase generates passphrases of random words, like diceware.
+type Passphrase struct {
+	// The number of words.
+	Words int
+
+	// What to put between the words.
+	Separator string
+
+	// Capitalize the first letter of every word.
+	Capitalize bool
+
+	// Add a random digit to one of the words.
+	Digit bool
+
+	// Add a random symbol to one of the words.
+	Symbol bool
+
+	// The words to choose from, defaults to the EFF large wordlist.
+	Wordlist []string
+}
+
+// EFFWordlist returns the words in the EFF large wordlist.
+func EFFWordlist() []string {
+	words, _ := parseWordlist(effLargeWordlist)
+	return words
+}
+
+// ReadWordlist reads a wordlist file with one word on every line.
+// Diceware lists, where every word is prefixed by its dice roll, are supported as well.
+func ReadWordlist(filename string) ([]string, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read wordlist '%s'", filename)
+	}
+
+	words, err := parseWordlist(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("invalid wordlist '%s': %s", filename, err)
+	}
+
+	return words, nil
+}
+
+func parseWordlist(content string) ([]string, error) {
+	seen := map[string]bool{}
+	words := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := fields[0]
+		if len(fields) == 2 && isRoll(fields[0]) {
+			word = fields[1]
+		}
+
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+
+	if len(words) < 2 {
+		return nil, errors.New("not enough words")
+	}
+
+	return words, nil
+}
+
+func isRoll(field string) bool {
+	for _, c := range field {
+		if c < '1' || c > '6' {
+			return false
+		}
+	}
+	return true
+}
+
+// Generate generates a passphrase.
+func (p *Passphrase) Generate() (string, error) {
+	if p.Words < 1 {
+		return "", errors.New("a passphrase needs at least one word")
+	}
+
+	wordlist := p.Wordlist
+	if len(wordlist) == 0 {
+		wordlist = EFFWordlist()
+	}
+
+	words := make([]string, p.Words)
+	for i := range words {
+		n, err := random(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+
+		words[i] = wordlist[n]
+		if p.Capitalize {
+			words[i] = capitalize(words[i])
+		}
+	}
+
+	if p.Digit {
+		err := addRandom(words, digit)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if p.Symbol {
+		err := addRandom(words, symbol)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(words, p.Separator), nil
+}
+
+// addRandom adds a random character from the alphabet to the end of a random word.
+func addRandom(words []string, alphabet string) error {
+	i, err := random(len(words))
+	if err != nil {
+		return err
+	}
+
+	c, err := random(len(alphabet))
+	if err != nil {
+		return err
+	}
+
+	words[i] += string(alphabet[c])
+	return nil
+}
+
+func capitalize(word string) string {
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,245 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The number of passwords to try before giving up on the maximum repeat of a policy.
+const maxAttempts = 100000
+
+// Policy describes the passwords to generate.
+type Policy struct {
+	// The length of the password.
+	Length int
+
+	// Do not use uppercase characters.
+	NoUpper bool
+
+	// Do not use digits.
+	NoDigits bool
+
+	// Do not use symbols.
+	NoSymbols bool
+
+	// The minimum number of characters of each class.
+	// They are ignored for classes that can not be used.
+	MinLower   int
+	MinUpper   int
+	MinDigits  int
+	MinSymbols int
+
+	// Only use these characters, when not empty.
+	Allowed string
+
+	// Never use these characters.
+	Forbidden string
+
+	// Do not use characters that are easily confused, like 0 and O.
+	NoAmbiguous bool
+
+	// The maximum number of times a character can be repeated in a row, 0 means no maximum.
+	MaxRepeat int
+}
+
+// DefaultPolicy is used when generating passwords, unless it is changed.
+// It requires at least one character of every class.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Length:     18,
+		MinLower:   1,
+		MinUpper:   1,
+		MinDigits:  1,
+		MinSymbols: 1,
+	}
+}
+
+// class is a set of characters with a minimum number of occurrences.
+type class struct {
+	name  string
+	chars string
+	min   int
+}
+
+// classes returns the character classes that can be used in the password.
+func (p *Policy) classes() ([]*class, error) {
+	classes := []*class{
+		{"lowercase", lower, p.MinLower},
+		{"uppercase", upper, p.MinUpper},
+		{"digit", digit, p.MinDigits},
+		{"symbol", symbol, p.MinSymbols},
+	}
+
+	disabled := map[string]bool{
+		"uppercase": p.NoUpper,
+		"digit":     p.NoDigits,
+		"symbol":    p.NoSymbols,
+	}
+
+	// Allowed characters that are not in any class count as symbols.
+	if p.Allowed != "" {
+		for _, c := range p.Allowed {
+			if !strings.ContainsRune(lower+upper+digit+classes[3].chars, c) {
+				classes[3].chars += string(c)
+			}
+		}
+	}
+
+	res := []*class{}
+	for _, cl := range classes {
+		chars := ""
+		for _, c := range cl.chars {
+			switch {
+			case disabled[cl.name]:
+			case p.Allowed != "" && !strings.ContainsRune(p.Allowed, c):
+			case strings.ContainsRune(p.Forbidden, c):
+			case p.NoAmbiguous && strings.ContainsRune(ambiguous, c):
+			default:
+				chars += string(c)
+			}
+		}
+
+		if chars == "" {
+			continue
+		}
+
+		res = append(res, &class{cl.name, chars, cl.min})
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("the policy does not allow any characters")
+	}
+
+	return res, nil
+}
+
+// Validate checks if passwords can be generated with the policy.
+func (p *Policy) Validate() error {
+	if p.Length < 1 {
+		return errors.New("the password length should be at least 1")
+	}
+
+	if p.MaxRepeat < 0 {
+		return errors.New("the maximum number of repeated characters can not be negative")
+	}
+
+	classes, err := p.classes()
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, cl := range classes {
+		if cl.min < 0 {
+			return fmt.Errorf("the minimum number of %s characters can not be negative", cl.name)
+		}
+		total += cl.min
+	}
+
+	if total > p.Length {
+		return fmt.Errorf("the policy requires %d characters, but the password is only %d characters long", total, p.Length)
+	}
+
+	return nil
+}
+
+// Generate generates a password that satisfies the policy.
+//
+// The minimum number of characters of every class are drawn from that class,
+// the rest from all allowed characters, and the result is shuffled.
+// Passwords that repeat characters too often are rejected.
+func (p *Policy) Generate() (string, error) {
+	err := p.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	classes, err := p.classes()
+	if err != nil {
+		return "", err
+	}
+
+	alphabet := []rune{}
+	for _, cl := range classes {
+		alphabet = append(alphabet, []rune(cl.chars)...)
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		password := make([]rune, 0, p.Length)
+		for _, cl := range classes {
+			chars := []rune(cl.chars)
+			for j := 0; j < cl.min; j++ {
+				n, err := random(len(chars))
+				if err != nil {
+					return "", err
+				}
+				password = append(password, chars[n])
+			}
+		}
+
+		for len(password) < p.Length {
+			n, err := random(len(alphabet))
+			if err != nil {
+				return "", err
+			}
+			password = append(password, alphabet[n])
+		}
+
+		err := shuffle(password)
+		if err != nil {
+			return "", err
+		}
+
+		if p.satisfied(password, classes) {
+			return string(password), nil
+		}
+	}
+
+	return "", errors.New("could not generate a password for the policy, try making it less strict")
+}
+
+// shuffle shuffles the characters in place with Fisher-Yates.
+func shuffle(password []rune) error {
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := random(i + 1)
+		if err != nil {
+			return err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return nil
+}
+
+// satisfied checks the minimums and repeats of the policy.
+func (p *Policy) satisfied(password []rune, classes []*class) bool {
+	for _, cl := range classes {
+		count := 0
+		for _, c := range password {
+			if strings.ContainsRune(cl.chars, c) {
+				count++
+			}
+		}
+
+		if count < cl.min {
+			return false
+		}
+	}
+
+	if p.MaxRepeat > 0 {
+		repeat := 1
+		for i := 1; i < len(password); i++ {
+			if password[i] == password[i-1] {
+				repeat++
+			} else {
+				repeat = 1
+			}
+
+			if repeat > p.MaxRepeat {
+				return false
+			}
+		}
+	}
+
+	return true
+}
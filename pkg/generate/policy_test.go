@@ -0,0 +1,251 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// The number of passwords to generate for every policy.
+const samples = 200
+
+func count(password string, chars string) int {
+	n := 0
+	for _, c := range password {
+		if strings.ContainsRune(chars, c) {
+			n++
+		}
+	}
+	return n
+}
+
+func maxRepeat(password string) int {
+	runes := []rune(password)
+	res, repeat := 0, 0
+	for i := range runes {
+		if i > 0 && runes[i] == runes[i-1] {
+			repeat++
+		} else {
+			repeat = 1
+		}
+		res = max(res, repeat)
+	}
+	return res
+}
+
+func TestPolicyGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		check  func(password string) bool
+	}{
+		{
+			name:   "default",
+			policy: DefaultPolicy(),
+			check: func(pw string) bool {
+				return len(pw) == 18 && count(pw, lower) >= 1 && count(pw, upper) >= 1 && count(pw, digit) >= 1 && count(pw, symbol) >= 1
+			},
+		},
+		{
+			name: "minimums",
+			policy: &Policy{
+				Length:     8,
+				MinLower:   2,
+				MinUpper:   2,
+				MinDigits:  2,
+				MinSymbols: 2,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 8 && count(pw, lower) == 2 && count(pw, upper) == 2 && count(pw, digit) == 2 && count(pw, symbol) == 2
+			},
+		},
+		{
+			name: "high minimum",
+			policy: &Policy{
+				Length:    10,
+				MinDigits: 8,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 10 && count(pw, digit) >= 8
+			},
+		},
+		{
+			name: "minimums fill the password",
+			policy: &Policy{
+				Length:     40,
+				MinLower:   10,
+				MinUpper:   10,
+				MinDigits:  10,
+				MinSymbols: 10,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 40 && count(pw, lower) == 10 && count(pw, upper) == 10 && count(pw, digit) == 10 && count(pw, symbol) == 10
+			},
+		},
+		{
+			name: "only digits",
+			policy: &Policy{
+				Length:    6,
+				NoUpper:   true,
+				NoSymbols: true,
+				MinDigits: 6,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 6 && count(pw, digit) == 6
+			},
+		},
+		{
+			name: "no uppercase, digits or symbols",
+			policy: &Policy{
+				Length:     12,
+				NoUpper:    true,
+				NoDigits:   true,
+				NoSymbols:  true,
+				MinUpper:   1,
+				MinDigits:  1,
+				MinSymbols: 1,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 12 && count(pw, lower) == 12
+			},
+		},
+		{
+			name: "allowed",
+			policy: &Policy{
+				Length:     10,
+				Allowed:    "abc123-",
+				MinUpper:   1,
+				MinSymbols: 1,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 10 && count(pw, "abc123-") == 10 && count(pw, "-") >= 1
+			},
+		},
+		{
+			name: "allowed characters outside the classes count as symbols",
+			policy: &Policy{
+				Length:     10,
+				Allowed:    "ab€",
+				MinSymbols: 2,
+			},
+			check: func(pw string) bool {
+				return count(pw, "ab€") == 10 && count(pw, "€") >= 2
+			},
+		},
+		{
+			name: "forbidden",
+			policy: &Policy{
+				Length:    40,
+				Forbidden: "aeiouAEIOU\"'`",
+				MinLower:  5,
+			},
+			check: func(pw string) bool {
+				return count(pw, "aeiouAEIOU\"'`") == 0 && count(pw, lower) >= 5
+			},
+		},
+		{
+			name: "no ambiguous",
+			policy: &Policy{
+				Length:      40,
+				NoAmbiguous: true,
+				MinDigits:   3,
+			},
+			check: func(pw string) bool {
+				return count(pw, ambiguous) == 0 && count(pw, digit) >= 3
+			},
+		},
+		{
+			name: "max repeat",
+			policy: &Policy{
+				Length:    20,
+				Allowed:   "ab",
+				MaxRepeat: 2,
+			},
+			check: func(pw string) bool {
+				return len(pw) == 20 && maxRepeat(pw) <= 2
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < samples; i++ {
+				password, err := test.policy.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !test.check(password) {
+					t.Fatalf("password %q does not satisfy the policy", password)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *Policy
+		err    string
+	}{
+		{
+			name:   "no length",
+			policy: &Policy{},
+			err:    "the password length should be at least 1",
+		},
+		{
+			name:   "negative repeat",
+			policy: &Policy{Length: 10, MaxRepeat: -1},
+			err:    "the maximum number of repeated characters can not be negative",
+		},
+		{
+			name:   "negative minimum",
+			policy: &Policy{Length: 10, MinDigits: -1},
+			err:    "the minimum number of digit characters can not be negative",
+		},
+		{
+			name:   "minimums too long",
+			policy: &Policy{Length: 3, MinLower: 1, MinUpper: 1, MinDigits: 1, MinSymbols: 1},
+			err:    "the policy requires 4 characters, but the password is only 3 characters long",
+		},
+		{
+			name:   "no characters",
+			policy: &Policy{Length: 10, Allowed: "abc", Forbidden: "abc"},
+			err:    "the policy does not allow any characters",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Validate()
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected %q, got %v", test.err, err)
+			}
+
+			_, err = test.policy.Generate()
+			if err == nil {
+				t.Error("expected Generate to fail as well")
+			}
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	generator := &Generator{
+		LowerCase: true,
+		NoSymbols: true,
+	}
+
+	for i := 0; i < samples; i++ {
+		password, err := generator.Generate(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(password) != 16 || strings.IndexFunc(password, func(c rune) bool {
+			return !unicode.IsLower(c) && !unicode.IsDigit(c)
+		}) >= 0 {
+			t.Fatalf("unexpected password %q", password)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package git provides a minimal wrapper around the git binary.
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Repo is a git work tree.
+type Repo struct {
+	// The directory in the work tree to run git in.
+	Dir string
+
+	// The git binary to use.
+	Path string
+}
+
+// Open returns the repository containing dir.
+// It returns nil when dir is not in a git work tree.
+func Open(ctx context.Context, dir string) *Repo {
+	repo := &Repo{
+		Dir:  dir,
+		Path: "git",
+	}
+
+	out, err := repo.output(ctx, "rev-parse", "--is-inside-work-tree")
+	if err != nil || strings.TrimSpace(out) != "true" {
+		return nil
+	}
+
+	return repo
+}
+
+// Run runs a git command in the repository, attached to the terminal.
+func (r *Repo) Run(ctx context.Context, args ...string) error {
+	cmd := r.command(ctx, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Commit commits all changes to the paths with the message.
+// Nothing is committed when the paths did not change.
+func (r *Repo) Commit(ctx context.Context, message string, paths ...string) error {
+	paths, err := r.known(ctx, paths)
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	args := append([]string{"add", "--all", "--"}, paths...)
+	_, err = r.output(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	args = append([]string{"status", "--porcelain", "--"}, paths...)
+	status, err := r.output(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	args = []string{"commit", "--quiet", "--message", message}
+
+	// Like pass, sign commits when configured to do so.
+	sign, _ := r.output(ctx, "config", "--bool", "--get", "pass.signcommits")
+	if strings.TrimSpace(sign) == "true" {
+		args = append(args, "-S")
+	}
+
+	// Only commit the paths, leaving anything else that is staged alone.
+	args = append(args, "--only", "--")
+	args = append(args, paths...)
+
+	_, err = r.output(ctx, args...)
+	return err
+}
+
+// known filters out the paths that do not exist and were never committed,
+// since git refuses to add those.
+func (r *Repo) known(ctx context.Context, paths []string) ([]string, error) {
+	res := []string{}
+	for _, pth := range paths {
+		full := pth
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(r.Dir, pth)
+		}
+
+		if _, err := os.Lstat(full); err == nil {
+			res = append(res, pth)
+			continue
+		}
+
+		out, err := r.output(ctx, "ls-files", "--", pth)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(out) != "" {
+			res = append(res, pth)
+		}
+	}
+
+	return res, nil
+}
+
+// Revision is a commit that changed a file.
+type Revision struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// Log lists the revisions that changed the file at path, newest first.
+// The path is relative to the directory of the repository.
+func (r *Repo) Log(ctx context.Context, path string) ([]*Revision, error) {
+	out, err := r.output(ctx, "log", "--follow", "--format=%H%x00%an%x00%aI%x00%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*Revision{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "\x00", 4)
+		if len(parts) != 4 {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in git log: %s", parts[2])
+		}
+
+		res = append(res, &Revision{
+			Hash:    parts[0],
+			Author:  parts[1],
+			Date:    date,
+			Subject: parts[3],
+		})
+	}
+
+	return res, nil
+}
+
+// Show gets the contents of the file at path as it was in the revision.
+// The path is relative to the directory of the repository.
+func (r *Repo) Show(ctx context.Context, rev string, path string) ([]byte, error) {
+	out, err := r.output(ctx, "show", "--no-textconv", rev+":./"+filepath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(out), nil
+}
+
+func (r *Repo) command(ctx context.Context, args ...string) *exec.Cmd {
+	args = append([]string{"-C", r.Dir}, args...)
+	return exec.CommandContext(ctx, r.Path, args...)
+}
+
+// output runs a git command and returns what it printed.
+func (r *Repo) output(ctx context.Context, args ...string) (string, error) {
+	stderr := &bytes.Buffer{}
+
+	cmd := r.command(ctx, args...)
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,203 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a git repository in a temporary directory.
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	repo := &Repo{
+		Dir:  dir,
+		Path: "git",
+	}
+
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "tester"},
+		{"config", "user.email", "tester@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		_, err := repo.output(ctx, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return repo
+}
+
+func write(t *testing.T, repo *Repo, name string, content string) {
+	t.Helper()
+
+	filename := filepath.Join(repo.Dir, name)
+	err := os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filename, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// committed returns the subject and the changed paths of the last commit.
+func committed(t *testing.T, repo *Repo) (string, []string) {
+	t.Helper()
+
+	out, err := repo.output(context.Background(), "show", "--format=%s", "--name-only", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	paths := []string{}
+	for _, line := range lines[1:] {
+		if line != "" {
+			paths = append(paths, line)
+		}
+	}
+	sort.Strings(paths)
+
+	return lines[0], paths
+}
+
+func TestOpen(t *testing.T) {
+	repo := newTestRepo(t)
+
+	if Open(context.Background(), repo.Dir) == nil {
+		t.Error("expected to open the repository")
+	}
+
+	if Open(context.Background(), t.TempDir()) != nil {
+		t.Error("expected nil outside of a repository")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	write(t, repo, "web/example.gpg", "one")
+	write(t, repo, "mail.gpg", "two")
+
+	err := repo.Commit(ctx, "Add given password for web/example to store.", filepath.Join(repo.Dir, "web/example.gpg"), "mail.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, paths := committed(t, repo)
+	if subject != "Add given password for web/example to store." {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if want := []string{"mail.gpg", "web/example.gpg"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("committed %v, want %v", paths, want)
+	}
+}
+
+func TestCommitOnlyPaths(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	write(t, repo, "web/example.gpg", "one")
+	err := repo.Commit(ctx, "Add web/example.", "web/example.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unrelated changes that are staged should stay out of the commit.
+	write(t, repo, "staged.gpg", "staged")
+	_, err = repo.output(ctx, "add", "staged.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, repo, "untracked.gpg", "untracked")
+
+	write(t, repo, "web/example.gpg", "changed")
+	err = repo.Commit(ctx, "Edit web/example.", "web/example.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, paths := committed(t, repo)
+	if subject != "Edit web/example." {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if want := []string{"web/example.gpg"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("committed %v, want %v", paths, want)
+	}
+
+	status, err := repo.output(ctx, "status", "--porcelain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A  staged.gpg\n?? untracked.gpg\n"; status != want {
+		t.Errorf("unexpected status %q, want %q", status, want)
+	}
+}
+
+func TestCommitRemoved(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	write(t, repo, "web/example.gpg", "one")
+	err := repo.Commit(ctx, "Add web/example.", "web/example.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Remove(filepath.Join(repo.Dir, "web/example.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths that never existed are ignored.
+	err = repo.Commit(ctx, "Remove web/example from store.", "web/example.gpg", "never.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, paths := committed(t, repo)
+	if subject != "Remove web/example from store." {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if want := []string{"web/example.gpg"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("committed %v, want %v", paths, want)
+	}
+}
+
+func TestCommitUnchanged(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	write(t, repo, "web/example.gpg", "one")
+	err := repo.Commit(ctx, "Add web/example.", "web/example.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.Commit(ctx, "Nothing changed.", "web/example.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, _ := committed(t, repo)
+	if subject != "Add web/example." {
+		t.Errorf("expected no new commit, got %q", subject)
+	}
+}
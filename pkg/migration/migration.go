@@ -0,0 +1,244 @@
+// Package migration decodes the otpauth-migration:// uris that Google Authenticator
+// uses to export accounts.
+package migration
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Prefix is the prefix of all migration uris.
+const Prefix = "otpauth-migration://"
+
+// Account is a single otp account in a migration payload.
+type Account struct {
+	Secret    []byte
+	Name      string
+	Issuer    string
+	Algorithm string
+	Digits    int
+	Type      string
+	Counter   int64
+}
+
+// Parse decodes all the accounts in a migration uri.
+func Parse(uri string) ([]*Account, error) {
+	if !strings.HasPrefix(uri, Prefix) {
+		return nil, errors.New("not an otpauth-migration uri")
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.New("invalid otpauth-migration uri")
+	}
+
+	data := queryData(u.RawQuery)
+	if data == "" {
+		return nil, errors.New("no data in otpauth-migration uri")
+	}
+
+	// The data is base64 encoded, with or without padding.
+	buf, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		buf, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		if err != nil {
+			return nil, errors.New("invalid data in otpauth-migration uri")
+		}
+	}
+
+	accounts := []*Account{}
+	err = fields(buf, func(num int, value uint64, bytes []byte) error {
+		// repeated OtpParameters otp_parameters = 1;
+		if num != 1 || bytes == nil {
+			return nil
+		}
+
+		account, err := parseAccount(bytes)
+		if err != nil {
+			return err
+		}
+
+		accounts = append(accounts, account)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// queryData gets the data parameter from the query.
+// The exports are not always escaped, so a + is part of the base64 data
+// and not a space like url.ParseQuery would have it.
+func queryData(query string) string {
+	for _, param := range strings.Split(query, "&") {
+		key, value, _ := strings.Cut(param, "=")
+		if key != "data" {
+			continue
+		}
+
+		data, err := url.PathUnescape(value)
+		if err != nil {
+			return value
+		}
+		return data
+	}
+	return ""
+}
+
+func parseAccount(buf []byte) (*Account, error) {
+	account := &Account{
+		Algorithm: "SHA1",
+		Digits:    6,
+		Type:      "totp",
+	}
+
+	err := fields(buf, func(num int, value uint64, bytes []byte) error {
+		switch num {
+		case 1:
+			account.Secret = bytes
+		case 2:
+			account.Name = string(bytes)
+		case 3:
+			account.Issuer = string(bytes)
+		case 4:
+			switch value {
+			case 2:
+				account.Algorithm = "SHA256"
+			case 3:
+				account.Algorithm = "SHA512"
+			case 4:
+				account.Algorithm = "MD5"
+			}
+		case 5:
+			if value == 2 {
+				account.Digits = 8
+			}
+		case 6:
+			if value == 1 {
+				account.Type = "hotp"
+			}
+		case 7:
+			account.Counter = int64(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(account.Secret) == 0 {
+		return nil, fmt.Errorf("no secret for account '%s'", account.Name)
+	}
+
+	return account, nil
+}
+
+// AccountName is the name of the account, without the issuer prefix.
+func (a *Account) AccountName() string {
+	if a.Issuer != "" {
+		return strings.TrimPrefix(a.Name, a.Issuer+":")
+	}
+	return a.Name
+}
+
+// URI returns the otpauth:// uri for the account.
+func (a *Account) URI() string {
+	label := a.AccountName()
+	if a.Issuer != "" {
+		label = a.Issuer + ":" + label
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(a.Secret))
+	if a.Issuer != "" {
+		query.Set("issuer", a.Issuer)
+	}
+	query.Set("algorithm", a.Algorithm)
+	query.Set("digits", strconv.Itoa(a.Digits))
+	if a.Type == "hotp" {
+		query.Set("counter", strconv.FormatInt(a.Counter, 10))
+	} else {
+		query.Set("period", "30")
+	}
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     a.Type,
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
+// fields calls fn for every field in the protobuf message in buf.
+// Varint fields are passed as value, length delimited fields as bytes.
+func fields(buf []byte, fn func(num int, value uint64, bytes []byte) error) error {
+	for len(buf) > 0 {
+		key, n := varint(buf)
+		if n == 0 {
+			return errors.New("invalid migration payload")
+		}
+		buf = buf[n:]
+
+		num := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			value, n := varint(buf)
+			if n == 0 {
+				return errors.New("invalid migration payload")
+			}
+			buf = buf[n:]
+
+			err := fn(num, value, nil)
+			if err != nil {
+				return err
+			}
+		case 1:
+			if len(buf) < 8 {
+				return errors.New("invalid migration payload")
+			}
+			buf = buf[8:]
+		case 2:
+			size, n := varint(buf)
+			if n == 0 || uint64(len(buf)-n) < size {
+				return errors.New("invalid migration payload")
+			}
+			bytes := buf[n : n+int(size)]
+			buf = buf[n+int(size):]
+
+			err := fn(num, 0, bytes)
+			if err != nil {
+				return err
+			}
+		case 5:
+			if len(buf) < 4 {
+				return errors.New("invalid migration payload")
+			}
+			buf = buf[4:]
+		default:
+			return errors.New("invalid migration payload")
+		}
+	}
+
+	return nil
+}
+
+// varint decodes a protobuf varint, it returns 0 bytes read when buf is invalid.
+func varint(buf []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		value |= uint64(buf[i]&0x7f) << (7 * i)
+		if buf[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
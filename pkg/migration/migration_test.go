@@ -0,0 +1,94 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The export of two accounts, the base64 data contains a +, a / and padding.
+const exportData = "CjkKDUhlbGxvId6tvu/7774SGUV4YW1wbGU6YWxpY2VAZXhhbXBsZS5jb20aB0V4YW1wbGUgASgBMAIKHwoK+vv8/f7/AAECAxIDYm9iGgRCYW5rIAIoAjABOAUQARgBIAAouWA="
+
+var exportAccounts = []*Account{
+	{
+		Secret:    []byte("Hello!\xde\xad\xbe\xef\xfb\xef\xbe"),
+		Name:      "Example:alice@example.com",
+		Issuer:    "Example",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Type:      "totp",
+	},
+	{
+		Secret:    []byte{0xfa, 0xfb, 0xfc, 0xfd, 0xfe, 0xff, 0x00, 0x01, 0x02, 0x03},
+		Name:      "bob",
+		Issuer:    "Bank",
+		Algorithm: "SHA256",
+		Digits:    8,
+		Type:      "hotp",
+		Counter:   5,
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+	}{
+		{
+			name: "raw",
+			uri:  "otpauth-migration://offline?data=" + exportData,
+		},
+		{
+			name: "escaped",
+			uri:  "otpauth-migration://offline?data=CjkKDUhlbGxvId6tvu%2F7774SGUV4YW1wbGU6YWxpY2VAZXhhbXBsZS5jb20aB0V4YW1wbGUgASgBMAIKHwoK%2Bvv8%2Ff7%2FAAECAxIDYm9iGgRCYW5rIAIoAjABOAUQARgBIAAouWA%3D",
+		},
+		{
+			name: "without padding",
+			uri:  "otpauth-migration://offline?data=" + exportData[:len(exportData)-1],
+		},
+		{
+			name: "other parameters",
+			uri:  "otpauth-migration://offline?version=1&data=" + exportData + "&batch=1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accounts, err := Parse(test.uri)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(accounts, exportAccounts) {
+				t.Errorf("got %+v, want %+v", accounts, exportAccounts)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://totp/example?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://offline",
+		"otpauth-migration://offline?data=",
+		"otpauth-migration://offline?data=not*base64",
+		"otpauth-migration://offline?data=CjkK",
+	} {
+		_, err := Parse(uri)
+		if err == nil {
+			t.Errorf("Parse(%q): expected an error", uri)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	want := []string{
+		"otpauth://totp/Example:alice@example.com?algorithm=SHA1&digits=6&issuer=Example&period=30&secret=JBSWY3DPEHPK3PXP7PX34",
+		"otpauth://hotp/Bank:bob?algorithm=SHA256&counter=5&digits=8&issuer=Bank&secret=7L57Z7P674AACAQD",
+	}
+
+	for i, account := range exportAccounts {
+		if got := account.URI(); got != want[i] {
+			t.Errorf("URI() = %q, want %q", got, want[i])
+		}
+	}
+}
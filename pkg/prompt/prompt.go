@@ -0,0 +1,103 @@
+// Package prompt provides utilities to read secrets from the terminal or stdin.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Options configure how a secret is read.
+type Options struct {
+	// Read the full contents of the secret until EOF instead of a single password.
+	Multiline bool
+
+	// Echo the password while it is being typed.
+	Echo bool
+
+	// Where to read the secret from, defaults to os.Stdin.
+	Stdin io.Reader
+}
+
+// Secret reads the contents for the named secret.
+//
+// When stdin is a terminal, the password is asked twice without echoing it.
+// Otherwise the password is read from stdin, so it can be piped in.
+func Secret(name string, opts Options) (string, error) {
+	in := opts.Stdin
+	if in == nil {
+		in = os.Stdin
+	}
+
+	file, tty := in.(*os.File)
+	tty = tty && term.IsTerminal(int(file.Fd()))
+	stdin := bufio.NewReader(in)
+
+	if opts.Multiline {
+		if tty {
+			fmt.Fprintf(os.Stderr, "Enter contents of %s and press Ctrl+D when finished:\n\n", name)
+		}
+
+		buf, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("could not read contents of '%s'", name)
+		}
+
+		return string(buf), nil
+	}
+
+	if !tty || opts.Echo {
+		if tty {
+			fmt.Fprintf(os.Stderr, "Enter password for %s: ", name)
+		}
+
+		line, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("could not read password for '%s'", name)
+		}
+
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", errors.New("no password provided")
+		}
+
+		return password + "\n", nil
+	}
+
+	password, err := hidden(file, fmt.Sprintf("Enter password for %s: ", name))
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" {
+		return "", errors.New("no password provided")
+	}
+
+	again, err := hidden(file, fmt.Sprintf("Retype password for %s: ", name))
+	if err != nil {
+		return "", err
+	}
+
+	if password != again {
+		return "", errors.New("the entered passwords do not match")
+	}
+
+	return password + "\n", nil
+}
+
+// hidden reads a line from the terminal without echoing it.
+func hidden(file *os.File, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	buf, err := term.ReadPassword(int(file.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.New("could not read password")
+	}
+
+	return string(buf), nil
+}
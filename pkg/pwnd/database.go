@@ -0,0 +1,326 @@
+package pwnd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// PrefixLength is the length of the hex encoded prefixes of the ranges.
+const PrefixLength = 5
+
+// Hash is a type of hash used in the Pwned Passwords downloads.
+type Hash string
+
+const (
+	SHA1 Hash = "sha1"
+	NTLM Hash = "ntlm"
+)
+
+// hashLength returns the hash type for the length of a hex encoded hash.
+func hashLength(n int) (Hash, bool) {
+	switch n {
+	case 2 * sha1.Size:
+		return SHA1, true
+	case 2 * md4.Size:
+		return NTLM, true
+	}
+	return "", false
+}
+
+// Sum hashes the password like Pwned Passwords does, as uppercase hex.
+func (h Hash) Sum(password string) string {
+	switch h {
+	case NTLM:
+		m := md4.New()
+		for _, c := range utf16.Encode([]rune(password)) {
+			m.Write([]byte{byte(c), byte(c >> 8)})
+		}
+		return strings.ToUpper(hex.EncodeToString(m.Sum(nil)))
+	default:
+		sum := sha1.Sum([]byte(password))
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+}
+
+// Database checks passwords against a downloaded copy of Pwned Passwords, so
+// passwords can be checked without access to haveibeenpwned.com.
+//
+// It is either a file with all hashes ordered by hash, like
+// pwned-passwords-sha1-ordered-by-hash-v8.txt, or a directory with a range file
+// for every prefix, like the ones made by WriteRanges. Both SHA-1 and NTLM hashes
+// are supported.
+type Database struct {
+	path   string
+	ranges bool
+	hash   Hash
+}
+
+// NewDatabase opens the database at the path.
+func NewDatabase(path string) (*Database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not find pwnd database '%s'", path)
+	}
+
+	db := &Database{
+		path:   path,
+		ranges: info.IsDir(),
+	}
+
+	// The type of hash follows from the length of the hashes.
+	filename := path
+	extra := 0
+	if db.ranges {
+		filename, err = anyRangeFile(path)
+		if err != nil {
+			return nil, err
+		}
+		extra = PrefixLength
+	}
+
+	line, err := firstLine(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pwnd database '%s'", path)
+	}
+
+	key, _, _ := strings.Cut(line, ":")
+	hash, ok := hashLength(len(key) + extra)
+	if !ok {
+		return nil, fmt.Errorf("invalid pwnd database '%s'", path)
+	}
+	db.hash = hash
+
+	return db, nil
+}
+
+// Hash returns the type of hashes in the database.
+func (db *Database) Hash() Hash {
+	return db.hash
+}
+
+// Check returns how often the password was seen in breaches.
+func (db *Database) Check(ctx context.Context, password string) (int, error) {
+	hash := db.hash.Sum(password)
+
+	hashes, err := db.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return 0, err
+	}
+
+	return hashes[hash[PrefixLength:]], nil
+}
+
+// Range returns the counts of the pwnd hashes that start with the prefix, by the rest of the hash.
+func (db *Database) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToUpper(prefix)
+	if db.ranges {
+		buf, err := os.ReadFile(db.rangeFile(prefix))
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]int{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read pwnd database '%s'", db.rangeFile(prefix))
+		}
+
+		return parseRange(string(buf)), nil
+	}
+
+	file, err := os.Open(db.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pwnd database '%s'", db.path)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := lowerBound(file, info.Size(), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pwnd database '%s': %s", db.path, err)
+	}
+
+	res := map[string]int{}
+	for offset < info.Size() {
+		line, next, err := readLine(file, offset)
+		if err != nil {
+			return nil, fmt.Errorf("could not read pwnd database '%s': %s", db.path, err)
+		}
+		offset = next
+
+		key, count, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+
+		res[key[len(prefix):]] = count
+	}
+
+	return res, nil
+}
+
+func (db *Database) rangeFile(prefix string) string {
+	return filepath.Join(db.path, prefix+".txt")
+}
+
+// anyRangeFile finds a range file in the directory, without listing all of them.
+func anyRangeFile(dir string) (string, error) {
+	file, err := os.Open(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not read pwnd database '%s'", dir)
+	}
+	defer file.Close()
+
+	for {
+		entries, err := file.ReadDir(100)
+		for _, entry := range entries {
+			if isRangeFile(entry.Name()) && entry.Type().IsRegular() {
+				return filepath.Join(dir, entry.Name()), nil
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("no range files found in pwnd database '%s'", dir)
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not read pwnd database '%s'", dir)
+		}
+	}
+}
+
+// isRangeFile reports if the name is a range file, like 5BAA6.txt.
+func isRangeFile(name string) bool {
+	prefix, ok := strings.CutSuffix(name, ".txt")
+	if !ok || len(prefix) != PrefixLength {
+		return false
+	}
+
+	return strings.Trim(prefix, "0123456789ABCDEF") == ""
+}
+
+func firstLine(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	line, _, err := readLine(file, 0)
+	return line, err
+}
+
+// lowerBound finds the offset of the first line with a key that is not less than the key,
+// in a file of "KEY:COUNT" lines ordered by key.
+// It does a binary search on the offsets in the file, so only a few lines are read.
+func lowerBound(r io.ReaderAt, size int64, key string) (int64, error) {
+	// The line we are looking for starts between lo and hi, or at hi.
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, err := lineStart(r, mid)
+		if err != nil {
+			return 0, err
+		}
+
+		// The line around the middle started before it, try the first line instead.
+		if start >= hi {
+			start = lo
+		}
+
+		line, next, err := readLine(r, start)
+		if err != nil {
+			return 0, err
+		}
+
+		k, _, _ := strings.Cut(line, ":")
+		if strings.ToUpper(k) < key {
+			lo = next
+		} else {
+			hi = start
+		}
+	}
+
+	return lo, nil
+}
+
+// parseRange parses the "SUFFIX:COUNT" lines of a range, skipping the padding
+// entries that have a count of 0.
+func parseRange(body string) map[string]int {
+	res := map[string]int{}
+	for _, line := range strings.Split(body, "\n") {
+		key, count, ok := parseLine(line)
+		if !ok || count == 0 {
+			continue
+		}
+		res[key] = count
+	}
+	return res
+}
+
+// parseLine parses a "KEY:COUNT" line.
+func parseLine(line string) (string, int, bool) {
+	key, count, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return "", 0, false
+	}
+
+	return strings.ToUpper(strings.TrimSpace(key)), n, true
+}
+
+// lineStart finds the start of the first line at or after the offset.
+func lineStart(r io.ReaderAt, offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+
+	_, next, err := readLine(r, offset-1)
+	return next, err
+}
+
+// readLine reads the line from the offset, without the line ending.
+// It also returns the offset of the next line.
+func readLine(r io.ReaderAt, offset int64) (string, int64, error) {
+	buf := make([]byte, 128)
+	line := []byte{}
+	for {
+		n, err := r.ReadAt(buf, offset+int64(len(line)))
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			line = append(line, buf[:i]...)
+			return strings.TrimRight(string(line), "\r"), offset + int64(len(line)) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+
+		if errors.Is(err, io.EOF) {
+			return strings.TrimRight(string(line), "\r"), offset + int64(len(line)), nil
+		}
+		if err != nil {
+			return "", 0, err
+		}
+	}
+}
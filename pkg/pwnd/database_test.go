@@ -0,0 +1,257 @@
+package pwnd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// hashes are the lines of a small database, ordered by hash.
+var hashes = []string{
+	"0000000000000000000000000000000000000000:1",
+	"00000FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:2",
+	"000010000000000000000000000000000000000A:3",
+	"000010000000000000000000000000000000000B:4",
+	"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+	"5BAA7000000000000000000000000000000000FF:5",
+	"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:6",
+}
+
+func writeDatabase(t *testing.T, lines []string, ending string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	err := os.WriteFile(filename, []byte(strings.Join(lines, ending)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLowerBound(t *testing.T) {
+	for _, ending := range []string{"\n", "\r\n"} {
+		content := strings.Join(hashes, ending)
+
+		// The offsets of the lines, and the end of the file.
+		offsets := []int64{}
+		offset := int64(0)
+		for _, line := range hashes {
+			offsets = append(offsets, offset)
+			offset += int64(len(line) + len(ending))
+		}
+		size := int64(len(content))
+
+		tests := []struct {
+			key  string
+			want int64
+		}{
+			{key: "0000000000000000000000000000000000000000", want: offsets[0]},
+			{key: "00000", want: offsets[0]},
+			{key: "00000F", want: offsets[1]},
+			{key: "00001", want: offsets[2]},
+			{key: "000010000000000000000000000000000000000B", want: offsets[3]},
+			{key: "5BAA6", want: offsets[4]},
+			{key: "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD9", want: offsets[5]},
+			{key: "5BAA7", want: offsets[5]},
+			{key: "5BAA8", want: offsets[6]},
+			{key: "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", want: offsets[6]},
+			{key: "G", want: size},
+		}
+
+		for _, test := range tests {
+			got, err := lowerBound(strings.NewReader(content), size, test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("lowerBound(%q) with %q endings = %d, want %d", test.key, ending, got, test.want)
+			}
+		}
+	}
+}
+
+func TestLowerBoundEmpty(t *testing.T) {
+	got, err := lowerBound(strings.NewReader(""), 0, "5BAA6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+}
+
+func TestDatabaseFile(t *testing.T) {
+	for _, ending := range []string{"\n", "\r\n"} {
+		db, err := NewDatabase(writeDatabase(t, hashes, ending))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testDatabase(t, db)
+	}
+}
+
+func TestDatabaseRanges(t *testing.T) {
+	dir := t.TempDir()
+
+	n, err := WriteRanges(strings.NewReader(strings.Join(hashes, "\n")), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("expected 5 range files, got %d", n)
+	}
+
+	db, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testDatabase(t, db)
+}
+
+func TestDatabaseRangesWithoutFirst(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := WriteRanges(strings.NewReader(strings.Join(hashes[4:], "\n")), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other files in the directory are skipped.
+	err = os.WriteFile(filepath.Join(dir, "README.txt"), []byte("ranges\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Hash() != SHA1 {
+		t.Errorf("expected sha1 hashes, got %s", db.Hash())
+	}
+
+	count, err := db.Check(context.Background(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 9545824 {
+		t.Errorf("expected 9545824, got %d", count)
+	}
+
+	hashes, err := db.Range(context.Background(), "00000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 0 {
+		t.Errorf("expected an empty range, got %v", hashes)
+	}
+}
+
+func TestDatabaseNTLM(t *testing.T) {
+	// NTLM 8846F7EAEE8FB117AD06BDD830B7586C
+	lines := []string{
+		"00000000000000000000000000000000:1",
+		"8846F7EAEE8FB117AD06BDD830B7586C:42",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:2",
+	}
+
+	db, err := NewDatabase(writeDatabase(t, lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Hash() != NTLM {
+		t.Errorf("expected ntlm hashes, got %s", db.Hash())
+	}
+
+	count, err := db.Check(context.Background(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 42 {
+		t.Errorf("expected 42, got %d", count)
+	}
+}
+
+func TestDatabaseInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"missing":      filepath.Join(dir, "missing.txt"),
+		"empty dir":    dir,
+		"invalid":      writeDatabase(t, []string{"not a hash:1"}, "\n"),
+		"short hashes": writeDatabase(t, []string{"ABCDEF:1"}, "\n"),
+	}
+
+	for name, path := range tests {
+		_, err := NewDatabase(path)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// testDatabase checks the database has the hashes.
+func testDatabase(t *testing.T, db *Database) {
+	t.Helper()
+	ctx := context.Background()
+
+	if db.Hash() != SHA1 {
+		t.Errorf("expected sha1 hashes, got %s", db.Hash())
+	}
+
+	ranges := map[string]map[string]int{}
+	for _, line := range hashes {
+		key, count, _ := parseLine(line)
+		prefix := key[:PrefixLength]
+		if ranges[prefix] == nil {
+			ranges[prefix] = map[string]int{}
+		}
+		ranges[prefix][key[PrefixLength:]] = count
+	}
+
+	prefixes := []string{}
+	for prefix := range ranges {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		got, err := db.Range(ctx, strings.ToLower(prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, ranges[prefix]) {
+			t.Errorf("Range(%q) = %v, want %v", prefix, got, ranges[prefix])
+		}
+	}
+
+	for _, prefix := range []string{"00002", "5BAA5", "FFFFE"} {
+		got, err := db.Range(ctx, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("Range(%q) = %v, want an empty range", prefix, got)
+		}
+	}
+
+	tests := map[string]int{
+		"password":     9545824,
+		"not pwnd yet": 0,
+	}
+	for password, want := range tests {
+		count, err := db.Check(ctx, password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Errorf("Check(%q) = %d, want %d", password, count, want)
+		}
+	}
+}
@@ -1,70 +1,149 @@
 package pwnd
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultURL is the url of the Pwned Passwords range API.
+const DefaultURL = "https://api.pwnedpasswords.com"
+
+// userAgent is required by haveibeenpwned.com.
+const userAgent = "spass"
+
+// Checker checks if passwords have been pwnd.
+type Checker interface {
+	// Hash returns the type of hashes the checker uses.
+	Hash() Hash
+
+	// Range returns the counts of the pwnd hashes that start with the prefix.
+	Range(ctx context.Context, prefix string) (map[string]int, error)
+
+	// Check returns how often the password was seen in breaches.
+	Check(ctx context.Context, password string) (int, error)
+}
+
+// RateLimitError is returned when the API limits the number of requests.
+type RateLimitError struct {
+	// How long to wait before trying again, 0 if the API did not say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter == 0 {
+		return "too many requests to haveibeenpwned.com"
+	}
+	return fmt.Sprintf("too many requests to haveibeenpwned.com, retry after %s", e.RetryAfter)
+}
+
+// Client checks passwords with the Pwned Passwords range API, only the first
+// characters of the hash of a password are sent.
 type Client struct {
 	apiKey string
+	url    string
+	http   *http.Client
 }
 
+// NewClient creates a client for haveibeenpwned.com.
 func NewClient(apiKey string) *Client {
+	return NewClientWithURL(apiKey, DefaultURL, nil)
+}
+
+// NewClientWithURL creates a client for the API at the url that makes its
+// requests with the http client, for instance a mirror or an httptest server.
+// When the http client is nil, a client with a timeout is used.
+func NewClientWithURL(apiKey string, url string, client *http.Client) *Client {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 30 * time.Second,
+		}
+	}
+
 	return &Client{
 		apiKey: apiKey,
+		url:    strings.TrimRight(url, "/"),
+		http:   client,
 	}
 }
 
-func (c *Client) Check(password string) (bool, error) {
-	client := &http.Client{}
+// Hash returns the type of hashes the API uses.
+func (c *Client) Hash() Hash {
+	return SHA1
+}
+
+// Check returns how often the password was seen in breaches.
+func (c *Client) Check(ctx context.Context, password string) (int, error) {
+	hash := SHA1.Sum(password)
 
-	h := sha1.New()
-	io.WriteString(h, password)
+	hashes, err := c.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return 0, err
+	}
 
-	hash := strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
-	prefix := hash[:5]
+	return hashes[hash[PrefixLength:]], nil
+}
 
-	url := fmt.Sprintf("https://api.pwnedpasswords.com/range/%s", prefix)
-	req, err := http.NewRequest("GET", url, nil)
+// Range returns the counts of the pwnd hashes that start with the prefix, by the rest of the hash.
+// The padding the API adds to hide the size of the range is left out.
+func (c *Client) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	url := fmt.Sprintf("%s/range/%s", c.url, strings.ToUpper(prefix))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	req.Header.Add("hibp-api-key", c.apiKey)
-	req.Header.Add("Agent", "spass 1.0")
-	req.Header.Add("Add-Padding", "true")
+	if c.apiKey != "" {
+		req.Header.Set("hibp-api-key", c.apiKey)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Add-Padding", "true")
 
-	resp, err := client.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return false, fmt.Errorf("failed to fetch range from havibeenpwnd.com")
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to fetch range from haveibeenpwned.com: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
-	if resp.StatusCode != 200 {
-		return false, fmt.Errorf("response %d from haveibeenpwned.com", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response %d from haveibeenpwned.com", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return false, fmt.Errorf("failed to read response body from havibeenpwnd.com")
+		return nil, fmt.Errorf("failed to read response body from haveibeenpwned.com")
 	}
 
-	for _, line := range strings.Split(string(body), "\n") {
-		parts := strings.Split(line, ":")
-		if parts[1] == "0" {
-			continue
-		}
+	return parseRange(string(body)), nil
+}
 
-		sum := prefix + parts[0]
-		if sum != hash {
-			continue
-		}
+// retryAfter parses a Retry-After header, which is either a number of seconds or a date.
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
 
-		return true, nil
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now).Round(time.Second)
 	}
 
-	return false, nil
+	return 0
 }
@@ -0,0 +1,159 @@
+package pwnd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientRange(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+
+		if r.URL.Path != "/range/5BAA6" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, "003D68EB55068C33ACE09247EE4C639306B:3\r\n")
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n")
+		fmt.Fprint(w, "01330C689E5D64F660D6947A93AD634EF8F:0\r\n")
+		fmt.Fprint(w, "011053FD0102E94D6AE2F8B83D76FAF94F6:0")
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("secret", server.URL+"/", server.Client())
+
+	hashes, err := client.Range(context.Background(), "5baa6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{
+		"003D68EB55068C33ACE09247EE4C639306B": 3,
+		"1E4C9B93F3F0682250B6CF8331B7EE68FD8": 9545824,
+	}
+	if !reflect.DeepEqual(hashes, want) {
+		t.Errorf("got %v, want %v", hashes, want)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+
+	headers := map[string]string{
+		"User-Agent":   "spass",
+		"Add-Padding":  "true",
+		"Hibp-Api-Key": "secret",
+	}
+	for key, value := range headers {
+		if got := requests[0].Header.Get(key); got != value {
+			t.Errorf("expected header %s to be %q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestClientCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n")
+		fmt.Fprint(w, "1E4C9B93F3F0682250B6CF8331B7EE68FD9:0\r\n")
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("", server.URL, server.Client())
+
+	tests := []struct {
+		password string
+		count    int
+	}{
+		// SHA-1 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+		{password: "password", count: 9545824},
+		{password: "not in the range", count: 0},
+	}
+
+	for _, test := range tests {
+		count, err := client.Check(context.Background(), test.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.count {
+			t.Errorf("Check(%q) = %d, want %d", test.password, count, test.count)
+		}
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "2", want: 2 * time.Second},
+		{header: "", want: 0},
+		{header: "soon", want: 0},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.header != "" {
+				w.Header().Set("Retry-After", test.header)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+
+		client := NewClientWithURL("", server.URL, server.Client())
+		_, err := client.Range(context.Background(), "5BAA6")
+		server.Close()
+
+		var limited *RateLimitError
+		if !errors.As(err, &limited) {
+			t.Errorf("Retry-After %q: expected a RateLimitError, got %v", test.header, err)
+			continue
+		}
+
+		if limited.RetryAfter != test.want {
+			t.Errorf("Retry-After %q: got %s, want %s", test.header, limited.RetryAfter, test.want)
+		}
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("", server.URL, server.Client())
+	_, err := client.Range(context.Background(), "5BAA6")
+	if err == nil || err.Error() != "response 503 from haveibeenpwned.com" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "3", want: 3 * time.Second},
+		{header: " 10 ", want: 10 * time.Second},
+		{header: "0", want: 0},
+		{header: "-1", want: 0},
+		{header: "Mon, 01 Jan 2024 12:01:30 GMT", want: 90 * time.Second},
+		{header: "Mon, 01 Jan 2024 11:00:00 GMT", want: 0},
+		{header: "later", want: 0},
+	}
+
+	for _, test := range tests {
+		if got := retryAfter(test.header, now); got != test.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", test.header, got, test.want)
+		}
+	}
+}
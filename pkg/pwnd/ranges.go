@@ -0,0 +1,81 @@
+package pwnd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteRanges splits a Pwned Passwords file ordered by hash into a range file for
+// every prefix in the directory, like the responses of the range API.
+// It returns the number of range files written.
+func WriteRanges(dump io.Reader, dir string) (int, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	var file *os.File
+	var w *bufio.Writer
+	flush := func() error {
+		if file == nil {
+			return nil
+		}
+
+		err := w.Flush()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	count := 0
+	prefix := ""
+	scanner := bufio.NewScanner(dump)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		hash, n, ok := strings.Cut(line, ":")
+		if _, valid := hashLength(len(hash)); !ok || !valid {
+			flush()
+			return count, fmt.Errorf("invalid hash on line %d", i)
+		}
+
+		hash = strings.ToUpper(hash)
+		if hash[:PrefixLength] < prefix {
+			flush()
+			return count, fmt.Errorf("hashes are not ordered on line %d", i)
+		}
+
+		if hash[:PrefixLength] != prefix {
+			err := flush()
+			if err != nil {
+				return count, err
+			}
+
+			prefix = hash[:PrefixLength]
+			file, err = os.Create(filepath.Join(dir, prefix+".txt"))
+			if err != nil {
+				return count, err
+			}
+			w = bufio.NewWriter(file)
+			count++
+		}
+
+		fmt.Fprintf(w, "%s:%s\n", hash[PrefixLength:], n)
+	}
+
+	if err := scanner.Err(); err != nil {
+		flush()
+		return count, err
+	}
+
+	return count, flush()
+}
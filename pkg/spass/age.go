@@ -0,0 +1,151 @@
+package spass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"golang.org/x/crypto/ssh"
+)
+
+// Age implements Crypto using age, compatible with passage.
+//
+// Recipients can be age X25519 recipients (age1...) or ssh public keys.
+type Age struct {
+	// The file containing the age identities or the ssh private key to decrypt with.
+	Identities string
+
+	// Passphrase asks for the passphrase of an encrypted ssh key.
+	Passphrase func(prompt string) ([]byte, error)
+
+	once       sync.Once
+	identities []age.Identity
+	err        error
+}
+
+// NewAge creates an Age backend that reads its identities from the identities file.
+func NewAge(identities string) *Age {
+	return &Age{
+		Identities: identities,
+		Passphrase: ttyPassphrase,
+	}
+}
+
+// ids reads the identities the first time they are needed.
+func (a *Age) ids() ([]age.Identity, error) {
+	a.once.Do(func() {
+		a.identities, a.err = a.readIdentities()
+	})
+	return a.identities, a.err
+}
+
+// Encrypt the plaintext for the age or ssh recipients.
+func (a *Age) Encrypt(ctx context.Context, recipients []string, plaintext []byte) ([]byte, error) {
+	to := []age.Recipient{}
+	for _, recipient := range recipients {
+		r, err := parseAgeRecipient(recipient)
+		if err != nil {
+			return nil, err
+		}
+		to = append(to, r)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := age.Encrypt(buf, to...)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	_, err = w.Write(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt the ciphertext with one of the identities.
+func (a *Age) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	ids, err := a.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), ids...)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt: %s", err)
+	}
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt: %s", err)
+	}
+
+	return buf, nil
+}
+
+// readIdentities reads an age identities file or an ssh private key.
+func (a *Age) readIdentities() ([]age.Identity, error) {
+	buf, err := os.ReadFile(a.Identities)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read age identities '%s'", a.Identities)
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(buf), []byte("-----BEGIN")) {
+		ids, err := age.ParseIdentities(bytes.NewReader(buf))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identities '%s': %s", a.Identities, err)
+		}
+		return ids, nil
+	}
+
+	id, err := agessh.ParseIdentity(buf)
+	if err == nil {
+		return []age.Identity{id}, nil
+	}
+
+	missing := &ssh.PassphraseMissingError{}
+	if !errors.As(err, &missing) || missing.PublicKey == nil {
+		return nil, fmt.Errorf("invalid ssh key '%s': %s", a.Identities, err)
+	}
+
+	passphrase := func() ([]byte, error) {
+		return a.Passphrase(fmt.Sprintf("passphrase for '%s': ", a.Identities))
+	}
+
+	id, err = agessh.NewEncryptedSSHIdentity(missing.PublicKey, buf, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh key '%s': %s", a.Identities, err)
+	}
+
+	return []age.Identity{id}, nil
+}
+
+// parseAgeRecipient parses an age X25519 recipient or an ssh public key.
+func parseAgeRecipient(recipient string) (age.Recipient, error) {
+	if strings.HasPrefix(recipient, "ssh-") {
+		r, err := agessh.ParseRecipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh recipient '%s': %s", recipient, err)
+		}
+		return r, nil
+	}
+
+	r, err := age.ParseX25519Recipient(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient '%s': %s", recipient, err)
+	}
+	return r, nil
+}
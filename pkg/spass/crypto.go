@@ -0,0 +1,52 @@
+package spass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Crypto encrypts and decrypts the contents of secrets.
+type Crypto interface {
+	// Encrypt the plaintext for all of the recipients.
+	Encrypt(ctx context.Context, recipients []string, plaintext []byte) ([]byte, error)
+
+	// Decrypt the ciphertext.
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// KeyLister is implemented by backends that can tell which keys a secret
+// is encrypted for, so secrets do not need to be re-encrypted needlessly.
+type KeyLister interface {
+	// Keys lists the ids of the encryption keys of the recipients.
+	Keys(ctx context.Context, recipients []string) ([]string, error)
+
+	// EncryptedFor lists the ids of the keys the ciphertext is encrypted for.
+	EncryptedFor(ctx context.Context, ciphertext []byte) ([]string, error)
+}
+
+// NewCrypto creates the crypto backend that is configured in the env.
+func NewCrypto(env *Env) (Crypto, error) {
+	switch env.SPASS_CRYPTO {
+	case "", "gpg":
+		return NewGPG(), nil
+	case "openpgp":
+		if env.SPASS_KEYRING == "" {
+			return nil, errors.New("the openpgp backend can not read the gpg keyring, export your keys with `gpg --export-secret-keys > keys.gpg` and set SPASS_KEYRING to that file")
+		}
+		return NewOpenPGP(env.SPASS_KEYRING), nil
+	default:
+		return nil, fmt.Errorf("unknown crypto backend '%s'", env.SPASS_CRYPTO)
+	}
+}
+
+// backend describes how the secrets of one crypto backend are stored.
+type backend struct {
+	// The file extension of the secrets.
+	ext string
+
+	// The name of the file listing the recipients.
+	idfile string
+
+	crypto Crypto
+}
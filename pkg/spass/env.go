@@ -2,14 +2,26 @@ package spass
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 )
 
 type Env struct {
-	PASSWORD_STORE_DIR    string
-	EDITOR                string
-	HAVEIBEENPWND_API_KEY string
+	PASSWORD_STORE_DIR     string
+	EDITOR                 string
+	HAVEIBEENPWND_API_KEY  string
+	HAVEIBEENPWND_DATABASE string
+	HAVEIBEENPWND_URL      string
+	SPASS_CRYPTO           string
+	SPASS_KEYRING          string
+
+	PASSAGE_IDENTITIES_FILE string
+
+	PASSWORD_STORE_CLIP_TIME string
+	SPASS_CLIPBOARD          string
 }
 
 func ReadEnv() *Env {
@@ -28,15 +40,75 @@ func ReadEnv() *Env {
 		pwnd = env
 	}
 
+	database := ""
+	if env := os.Getenv("HAVEIBEENPWND_DATABASE"); env != "" {
+		database = env
+	}
+
+	pwndURL := "https://api.pwnedpasswords.com"
+	if env := os.Getenv("HAVEIBEENPWND_URL"); env != "" {
+		pwndURL = env
+	}
+
+	crypto := "gpg"
+	if env := os.Getenv("SPASS_CRYPTO"); env != "" {
+		crypto = env
+	}
+
+	keyring := ""
+	if env := os.Getenv("SPASS_KEYRING"); env != "" {
+		keyring = env
+	}
+
+	identities := filepath.Join(os.Getenv("HOME"), ".passage", "identities")
+	if env := os.Getenv("PASSAGE_IDENTITIES_FILE"); env != "" {
+		identities = env
+	}
+
+	clip := "45"
+	if env := os.Getenv("PASSWORD_STORE_CLIP_TIME"); env != "" {
+		clip = env
+	}
+
+	clipboard := "auto"
+	if env := os.Getenv("SPASS_CLIPBOARD"); env != "" {
+		clipboard = env
+	}
+
 	return &Env{
-		PASSWORD_STORE_DIR:    dir,
-		EDITOR:                editor,
-		HAVEIBEENPWND_API_KEY: pwnd,
+		PASSWORD_STORE_DIR:     dir,
+		EDITOR:                 editor,
+		HAVEIBEENPWND_API_KEY:  pwnd,
+		HAVEIBEENPWND_DATABASE: database,
+		HAVEIBEENPWND_URL:      pwndURL,
+		SPASS_CRYPTO:           crypto,
+		SPASS_KEYRING:          keyring,
+
+		PASSAGE_IDENTITIES_FILE: identities,
+
+		PASSWORD_STORE_CLIP_TIME: clip,
+		SPASS_CLIPBOARD:          clipboard,
+	}
+}
+
+// ClipTime is how long copied secrets stay on the clipboard.
+func (env *Env) ClipTime() (time.Duration, error) {
+	seconds, err := strconv.Atoi(env.PASSWORD_STORE_CLIP_TIME)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid PASSWORD_STORE_CLIP_TIME '%s'", env.PASSWORD_STORE_CLIP_TIME)
 	}
+	return time.Duration(seconds) * time.Second, nil
 }
 
-func (env *Env) Print() {
-	fmt.Printf("PASSWORD_STORE_DIR=%s\n", env.PASSWORD_STORE_DIR)
-	fmt.Printf("EDITOR=%s\n", env.EDITOR)
-	fmt.Printf("HAVEIBEENPWND_API_KEY=%s\n", env.HAVEIBEENPWND_API_KEY)
+func (env *Env) Print(w io.Writer) {
+	fmt.Fprintf(w, "PASSWORD_STORE_DIR=%s\n", env.PASSWORD_STORE_DIR)
+	fmt.Fprintf(w, "EDITOR=%s\n", env.EDITOR)
+	fmt.Fprintf(w, "HAVEIBEENPWND_API_KEY=%s\n", env.HAVEIBEENPWND_API_KEY)
+	fmt.Fprintf(w, "HAVEIBEENPWND_DATABASE=%s\n", env.HAVEIBEENPWND_DATABASE)
+	fmt.Fprintf(w, "HAVEIBEENPWND_URL=%s\n", env.HAVEIBEENPWND_URL)
+	fmt.Fprintf(w, "SPASS_CRYPTO=%s\n", env.SPASS_CRYPTO)
+	fmt.Fprintf(w, "SPASS_KEYRING=%s\n", env.SPASS_KEYRING)
+	fmt.Fprintf(w, "PASSAGE_IDENTITIES_FILE=%s\n", env.PASSAGE_IDENTITIES_FILE)
+	fmt.Fprintf(w, "PASSWORD_STORE_CLIP_TIME=%s\n", env.PASSWORD_STORE_CLIP_TIME)
+	fmt.Fprintf(w, "SPASS_CLIPBOARD=%s\n", env.SPASS_CLIPBOARD)
 }
@@ -0,0 +1,147 @@
+package spass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GPG implements Crypto by shelling out to the gpg binary.
+type GPG struct {
+	// The gpg binary to use.
+	Path string
+}
+
+// NewGPG creates a GPG backend that uses the gpg binary from $PATH.
+func NewGPG() *GPG {
+	return &GPG{
+		Path: "gpg",
+	}
+}
+
+// Encrypt the plaintext using gpg.
+func (g *GPG) Encrypt(ctx context.Context, recipients []string, plaintext []byte) ([]byte, error) {
+	args := []string{}
+	for _, recipient := range recipients {
+		args = append(args, "--recipient", recipient)
+	}
+	args = append(args, "--encrypt")
+
+	cmd := exec.CommandContext(ctx, g.Path, args...)
+	cmd.Stdin = bytes.NewReader(plaintext)
+	return cmd.Output()
+}
+
+// Decrypt the ciphertext using gpg.
+func (g *GPG) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, g.Path, "--decrypt")
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	return cmd.Output()
+}
+
+// Keys lists the ids of the encryption subkeys gpg uses for the recipients.
+func (g *GPG) Keys(ctx context.Context, recipients []string) ([]string, error) {
+	args := []string{"--list-keys", "--with-colons", "--"}
+	args = append(args, recipients...)
+
+	cmd := exec.CommandContext(ctx, g.Path, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list keys for recipients: %s", err)
+	}
+
+	return encryptionKeys(string(out)), nil
+}
+
+// gpgKey is a key or subkey in the output of gpg --with-colons.
+type gpgKey struct {
+	id      string
+	created int64
+}
+
+// encryptionKeys picks the key gpg encrypts to for every primary key in the
+// output of gpg --list-keys --with-colons: the newest valid subkey that can
+// encrypt, or the primary key itself when there is none.
+func encryptionKeys(out string) []string {
+	res := []string{}
+
+	var primary *gpgKey
+	var subkey *gpgKey
+	valid := false
+	done := func() {
+		switch {
+		case !valid:
+		case subkey != nil:
+			res = append(res, subkey.id)
+		case primary != nil:
+			res = append(res, primary.id)
+		}
+		primary, subkey, valid = nil, nil, false
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 12 || (fields[0] != "pub" && fields[0] != "sub") {
+			continue
+		}
+
+		if fields[0] == "pub" {
+			done()
+		}
+
+		// Skip invalid, disabled, revoked and expired keys.
+		usable := !strings.ContainsAny(fields[1], "idre")
+		if fields[0] == "pub" {
+			valid = usable
+		}
+
+		if !usable || !strings.Contains(fields[11], "e") {
+			continue
+		}
+
+		created, _ := strconv.ParseInt(fields[5], 10, 64)
+		key := &gpgKey{
+			id:      fields[4],
+			created: created,
+		}
+
+		if fields[0] == "pub" {
+			primary = key
+		} else if subkey == nil || key.created >= subkey.created {
+			subkey = key
+		}
+	}
+	done()
+
+	return res
+}
+
+var gpgKeyID = regexp.MustCompile(` ID ([A-F0-9]{16})`)
+
+// EncryptedFor lists the ids of the keys the ciphertext is encrypted for using gpg.
+func (g *GPG) EncryptedFor(ctx context.Context, ciphertext []byte) ([]string, error) {
+	cmd := exec.CommandContext(ctx, g.Path, "-v", "--list-only", "--keyid-format", "long", "--decrypt")
+	cmd.Stdin = bytes.NewReader(ciphertext)
+
+	// gpg prints the key ids to stderr.
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("could not list the keys the secret is encrypted for: %s", err)
+	}
+
+	res := []string{}
+	for _, match := range gpgKeyID.FindAllStringSubmatch(string(out), -1) {
+		res = append(res, match[1])
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("could not find the keys the secret is encrypted for")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package spass
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncryptionKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		out  []string
+		want []string
+	}{
+		{
+			name: "subkey",
+			out: []string{
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scESC::::::23::0:",
+				"sub:u:3072:1:BBBBBBBBBBBBBBBB:1700000000::::::e::::::23:",
+			},
+			want: []string{"BBBBBBBBBBBBBBBB"},
+		},
+		{
+			name: "newest subkey",
+			out: []string{
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scESC::::::23::0:",
+				"sub:u:3072:1:BBBBBBBBBBBBBBBB:1700000000::::::e::::::23:",
+				"sub:u:3072:1:CCCCCCCCCCCCCCCC:1700000100::::::e::::::23:",
+				"sub:u:3072:1:DDDDDDDDDDDDDDDD:1700000200::::::s::::::23:",
+			},
+			want: []string{"CCCCCCCCCCCCCCCC"},
+		},
+		{
+			name: "expired and revoked subkeys",
+			out: []string{
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scESC::::::23::0:",
+				"sub:u:3072:1:BBBBBBBBBBBBBBBB:1700000000::::::e::::::23:",
+				"sub:e:3072:1:CCCCCCCCCCCCCCCC:1700000100:1700000200:::::e::::::23:",
+				"sub:r:3072:1:DDDDDDDDDDDDDDDD:1700000300::::::e::::::23:",
+			},
+			want: []string{"BBBBBBBBBBBBBBBB"},
+		},
+		{
+			name: "primary key",
+			out: []string{
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scESC::::::23::0:",
+			},
+			want: []string{},
+		},
+		{
+			name: "primary key that encrypts",
+			out: []string{
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scesCE::::::23::0:",
+				"sub:e:3072:1:BBBBBBBBBBBBBBBB:1700000000:1700000100:::::e::::::23:",
+			},
+			want: []string{"AAAAAAAAAAAAAAAA"},
+		},
+		{
+			name: "expired primary key",
+			out: []string{
+				"pub:e:3072:1:AAAAAAAAAAAAAAAA:1700000000:1700000100::u:::sc::::::23::0:",
+				"sub:u:3072:1:BBBBBBBBBBBBBBBB:1700000000::::::e::::::23:",
+			},
+			want: []string{},
+		},
+		{
+			name: "multiple recipients",
+			out: []string{
+				"tru::1:1792192545:0:3:1:5",
+				"pub:u:3072:1:AAAAAAAAAAAAAAAA:1700000000:::u:::scESC::::::23::0:",
+				"fpr:::::::::0000000000000000000000000000AAAAAAAAAAAAAAAA:",
+				"uid:u::::1700000000::0000::A <a@example.com>::::::::::0:",
+				"sub:u:3072:1:BBBBBBBBBBBBBBBB:1700000000::::::e::::::23:",
+				"pub:u:3072:1:CCCCCCCCCCCCCCCC:1700000000:::u:::scESC::::::23::0:",
+				"sub:u:3072:1:DDDDDDDDDDDDDDDD:1700000000::::::e::::::23:",
+			},
+			want: []string{"BBBBBBBBBBBBBBBB", "DDDDDDDDDDDDDDDD"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := encryptionKeys(strings.Join(test.out, "\n") + "\n")
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
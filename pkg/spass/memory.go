@@ -0,0 +1,185 @@
+package spass
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStore implements Store in memory, which is useful for testing.
+type MemoryStore struct {
+	crypto     Crypto
+	recipients []string
+
+	mu      sync.Mutex
+	secrets map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore that encrypts its secrets
+// with crypto for the recipients.
+func NewMemoryStore(crypto Crypto, recipients ...string) *MemoryStore {
+	return &MemoryStore{
+		crypto:     crypto,
+		recipients: recipients,
+		secrets:    map[string][]byte{},
+	}
+}
+
+// List the secrets in the store.
+// If namespace is not empty, only the secrets in that namespace are listed.
+func (s *MemoryStore) List(ctx context.Context, namespace string) ([]Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := []string{}
+	for name := range s.secrets {
+		if namespace == "" || strings.HasPrefix(name, namespace+"/") {
+			names = append(names, name)
+		}
+	}
+
+	if namespace != "" && len(names) == 0 {
+		return nil, fmt.Errorf("no namespace found named '%s'", namespace)
+	}
+
+	sort.Strings(names)
+
+	res := make([]Secret, 0, len(names))
+	for _, name := range names {
+		res = append(res, &MemorySecret{
+			store: s,
+			name:  name,
+		})
+	}
+
+	return res, nil
+}
+
+// Get a secret by name.
+func (s *MemoryStore) Secret(ctx context.Context, name string) (Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.secrets[name]; !ok {
+		return nil, fmt.Errorf("no secret found named '%s'", name)
+	}
+
+	return &MemorySecret{
+		store: s,
+		name:  name,
+	}, nil
+}
+
+// NewSecret returns a new secret.
+func (s *MemoryStore) NewSecret(ctx context.Context, name string) (Secret, error) {
+	return &MemorySecret{
+		store: s,
+		name:  name,
+	}, nil
+}
+
+// MemorySecret implements Secret for a MemoryStore.
+type MemorySecret struct {
+	store *MemoryStore
+	name  string
+}
+
+// FullName gets the name of the secret, including the namespace
+func (s *MemorySecret) FullName() string {
+	return s.name
+}
+
+// Name gets the name of secret
+func (s *MemorySecret) Name() string {
+	return path.Base(s.name)
+}
+
+// Namespace gets the namespace of the secret
+func (s *MemorySecret) Namespace() string {
+	dir := path.Dir(s.name)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// Body decrypts the full body of the secret
+func (s *MemorySecret) Body(ctx context.Context) (string, error) {
+	s.store.mu.Lock()
+	buf, ok := s.store.secrets[s.name]
+	s.store.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("no secret found named '%s'", s.name)
+	}
+
+	body, err := s.store.crypto.Decrypt(ctx, buf)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// Write encrypts and stores the full body of the secret
+func (s *MemorySecret) Write(ctx context.Context, content string) error {
+	buf, err := s.store.crypto.Encrypt(ctx, s.store.recipients, []byte(content))
+	if err != nil {
+		return err
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	s.store.secrets[s.name] = buf
+	return nil
+}
+
+// Password gets the password of the secret
+func (s *MemorySecret) Password(ctx context.Context) (string, error) {
+	body, err := s.Body(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return password(s.name, body)
+}
+
+// SetPassword sets the password, keeping the rest of the secret
+func (s *MemorySecret) SetPassword(ctx context.Context, password string) error {
+	body := ""
+	if _, err := s.store.Secret(ctx, s.name); err == nil {
+		body, err = s.Body(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Write(ctx, withPassword(body, password))
+}
+
+// Pairs gets the pairs in the secret
+func (s *MemorySecret) Pairs(ctx context.Context) ([]*Pair, error) {
+	body, err := s.Body(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs(body), nil
+}
+
+// Remove removes the secret
+func (s *MemorySecret) Remove(ctx context.Context) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	if _, ok := s.store.secrets[s.name]; !ok {
+		return fmt.Errorf("no secret found named '%s'", s.name)
+	}
+
+	delete(s.store.secrets, s.name)
+	return nil
+}
@@ -0,0 +1,287 @@
+package spass
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Move moves a secret or a whole namespace to a new name.
+// Secrets are re-encrypted when their recipients differ at the destination.
+// It returns the files it changed.
+func (s *FileStore) Move(ctx context.Context, from string, to string, force bool) ([]string, error) {
+	return s.transfer(ctx, from, to, force, true)
+}
+
+// Copy copies a secret or a whole namespace to a new name.
+// Secrets are re-encrypted when their recipients differ at the destination.
+// It returns the files it changed.
+func (s *FileStore) Copy(ctx context.Context, from string, to string, force bool) ([]string, error) {
+	return s.transfer(ctx, from, to, force, false)
+}
+
+func (s *FileStore) transfer(ctx context.Context, from string, to string, force bool, move bool) ([]string, error) {
+	root := s.env.PASSWORD_STORE_DIR
+	src := filepath.Join(root, from)
+	dst := filepath.Join(root, to)
+
+	if src == dst {
+		return nil, fmt.Errorf("source and destination are both '%s'", from)
+	}
+
+	// Like pass, move into the destination when it is a namespace.
+	if strings.HasSuffix(to, "/") || isDir(dst) {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+
+	secret, _ := s.secret(from)
+	if secret != nil && !(strings.HasSuffix(from, "/") && isDir(src)) {
+		name := strings.TrimPrefix(dst, root+"/")
+		changed, err := s.transferSecret(ctx, secret, name, force, move)
+		if err != nil {
+			return nil, err
+		}
+		return changed, nil
+	}
+
+	if !isDir(src) {
+		return nil, fmt.Errorf("no secret or namespace found named '%s'", from)
+	}
+
+	if strings.HasPrefix(dst, src+"/") {
+		return nil, fmt.Errorf("destination is inside of '%s'", from)
+	}
+
+	secrets := []*SecretFile{}
+	namespaceFiles := []string{}
+	err := filepath.Walk(src, func(pth string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if s.isIDFile(pth) || filepath.Base(pth) == PolicyFileName {
+			namespaceFiles = append(namespaceFiles, pth)
+			return nil
+		}
+
+		backend := s.backendFor(pth)
+		if backend == nil {
+			return nil
+		}
+
+		secrets = append(secrets, &SecretFile{
+			env:      s.env,
+			backend:  backend,
+			filename: pth,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Check all destinations first, so we do not stop halfway.
+	names := map[*SecretFile]string{}
+	for _, secret := range secrets {
+		rel := strings.TrimPrefix(strip(secret.filename), src+"/")
+		name := strings.TrimPrefix(filepath.Join(dst, rel), root+"/")
+		names[secret] = name
+
+		if existing, _ := s.secret(name); existing != nil && !force {
+			return nil, fmt.Errorf("secret '%s' already exists, pass --force to overwrite it", name)
+		}
+	}
+
+	changed := []string{}
+
+	// The recipients and policy files go along with the namespace, so they
+	// apply to the secrets at the destination too.
+	for _, file := range namespaceFiles {
+		target := filepath.Join(dst, strings.TrimPrefix(file, src+"/"))
+		if _, err := os.Stat(target); err != nil {
+			err = copyFile(file, target)
+			if err != nil {
+				return nil, err
+			}
+			changed = append(changed, target)
+		}
+	}
+
+	for _, secret := range secrets {
+		files, err := s.transferSecret(ctx, secret, names[secret], true, move)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, files...)
+	}
+
+	if move {
+		for _, file := range namespaceFiles {
+			err = os.Remove(file)
+			if err != nil {
+				return nil, fmt.Errorf("could not remove '%s'", file)
+			}
+			changed = append(changed, file)
+		}
+
+		removeEmptyDirs(src)
+	}
+
+	return changed, nil
+}
+
+// transferSecret moves or copies a single secret.
+func (s *FileStore) transferSecret(ctx context.Context, secret *SecretFile, name string, force bool, move bool) ([]string, error) {
+	existing, _ := s.secret(name)
+	if existing != nil && !force {
+		return nil, fmt.Errorf("secret '%s' already exists, pass --force to overwrite it", name)
+	}
+
+	target := s.newSecret(name)
+
+	changed, err := s.writeTransfer(ctx, secret, target, move)
+	if err != nil {
+		return nil, err
+	}
+
+	// The destination might be stored with another backend, it is only
+	// removed once the secret has been written.
+	if existing != nil && existing.filename != target.filename {
+		err = os.Remove(existing.filename)
+		if err != nil {
+			return nil, fmt.Errorf("could not remove '%s'", existing.filename)
+		}
+		changed = append(changed, existing.filename)
+	}
+
+	return changed, nil
+}
+
+// writeTransfer writes the secret to the target, re-encrypting it if needed.
+func (s *FileStore) writeTransfer(ctx context.Context, secret *SecretFile, target *SecretFile, move bool) ([]string, error) {
+	changed := []string{target.filename}
+
+	same, err := s.sameRecipients(secret, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if same {
+		err = os.MkdirAll(filepath.Dir(target.filename), 0700)
+		if err != nil {
+			return nil, fmt.Errorf("could not create namespace for secret '%s'", target.FullName())
+		}
+
+		if move {
+			err = os.Rename(secret.filename, target.filename)
+			if err != nil {
+				return nil, fmt.Errorf("could not move secret '%s'", secret.FullName())
+			}
+			return append(changed, secret.filename), nil
+		}
+
+		err = copyFile(secret.filename, target.filename)
+		if err != nil {
+			return nil, err
+		}
+		return changed, nil
+	}
+
+	body, err := secret.Body(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt secret '%s'", secret.FullName())
+	}
+
+	err = target.Write(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if move {
+		err = secret.Remove(ctx)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, secret.filename)
+	}
+
+	return changed, nil
+}
+
+// sameRecipients checks if two secrets are encrypted the same way.
+func (s *FileStore) sameRecipients(a *SecretFile, b *SecretFile) (bool, error) {
+	if a.backend != b.backend {
+		return false, nil
+	}
+
+	ra, err := a.recipients()
+	if err != nil {
+		return false, err
+	}
+
+	rb, err := b.recipients()
+	if err != nil {
+		return false, err
+	}
+
+	return sameKeys(ra, rb), nil
+}
+
+// isIDFile checks if the file lists the recipients of one of the backends.
+func (s *FileStore) isIDFile(filename string) bool {
+	for _, b := range s.backends {
+		if filepath.Base(filename) == b.idfile {
+			return true
+		}
+	}
+	return false
+}
+
+func isDir(pth string) bool {
+	info, err := os.Stat(pth)
+	return err == nil && info.IsDir()
+}
+
+func copyFile(from string, to string) error {
+	buf, err := os.ReadFile(from)
+	if err != nil {
+		return fmt.Errorf("could not read '%s'", from)
+	}
+
+	err = os.MkdirAll(filepath.Dir(to), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create directory for '%s'", to)
+	}
+
+	err = os.WriteFile(to, buf, 0644)
+	if err != nil {
+		return fmt.Errorf("could not write '%s'", to)
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes dir and all directories in it that are empty.
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	// Fails when the directory is not empty.
+	os.Remove(dir)
+}
@@ -0,0 +1,140 @@
+package spass
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T, files map[string]string) (*FileStore, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(filename), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filename, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := &Env{PASSWORD_STORE_DIR: dir}
+	return NewFileStoreWithCrypto(env, &TestCrypto{}), dir
+}
+
+func TestMoveKeepsDestinationWhenWriteFails(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestFileStore(t, map[string]string{
+		".gpg-id":     "alice\n",
+		"web/.gpg-id": "bob\n",
+		"web/bad.gpg": "garbage\n",
+		"keep.age":    "precious\n",
+	})
+
+	_, err := store.Move(ctx, "web/bad", "keep", true)
+	if err == nil {
+		t.Fatal("expected an error when the secret cannot be decrypted")
+	}
+
+	buf, err := os.ReadFile(filepath.Join(dir, "keep.age"))
+	if err != nil {
+		t.Fatalf("destination was removed: %s", err)
+	}
+	if string(buf) != "precious\n" {
+		t.Errorf("destination was changed to %q", buf)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "web/bad.gpg")); err != nil {
+		t.Errorf("source was removed: %s", err)
+	}
+}
+
+func TestMoveReplacesDestinationWithOtherBackend(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestFileStore(t, map[string]string{
+		".gpg-id":  "alice\n",
+		"keep.age": "precious\n",
+	})
+
+	secret, err := store.NewSecret(ctx, "web/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = secret.Write(ctx, "hunter2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Move(ctx, "web/new", "keep", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "keep.age")); !os.IsNotExist(err) {
+		t.Errorf("old destination was not removed")
+	}
+
+	moved, err := store.Secret(ctx, "keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := moved.Body(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "hunter2\n" {
+		t.Errorf("expected the moved body, got %q", body)
+	}
+}
+
+func TestMoveNamespaceKeepsPolicy(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestFileStore(t, map[string]string{
+		".gpg-id":            "alice\n",
+		"bank/.gpg-id":       "bob\n",
+		"bank/.spass-policy": "length: 12\n",
+	})
+
+	secret, err := store.NewSecret(ctx, "bank/checking")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = secret.Write(ctx, "hunter2\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := store.Move(ctx, "bank", "finance", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{".gpg-id", ".spass-policy", "checking.gpg"} {
+		if _, err := os.Stat(filepath.Join(dir, "finance", name)); err != nil {
+			t.Errorf("expected %s to be moved: %s", name, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "bank")); !os.IsNotExist(err) {
+		t.Error("expected the old namespace to be removed")
+	}
+
+	found := false
+	for _, filename := range changed {
+		if filename == filepath.Join(dir, "bank", ".spass-policy") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the old policy file to be changed, got %v", changed)
+	}
+
+	if got := store.PolicyFile("finance/checking"); got != filepath.Join(dir, "finance", ".spass-policy") {
+		t.Errorf("unexpected policy file %q", got)
+	}
+}
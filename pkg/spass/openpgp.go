@@ -0,0 +1,299 @@
+package spass
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"golang.org/x/term"
+)
+
+// The number of times we ask for the passphrase of a private key.
+const passphraseAttempts = 3
+
+// OpenPGP implements Crypto natively, without the need for a gpg binary.
+//
+// It does not use the keyring of gpg 2.1 and later, which keeps its keys in a
+// keybox and the private keys in the agent. Instead, the keys are read from a
+// file exported with `gpg --export-secret-keys`, or from a directory with the
+// legacy pubring.gpg and secring.gpg keyrings.
+type OpenPGP struct {
+	// The key file or keyring directory to read the keys from.
+	Keyring string
+
+	// Passphrase asks for the passphrase of a private key.
+	Passphrase func(prompt string) ([]byte, error)
+
+	once     sync.Once
+	entities openpgp.EntityList
+	err      error
+}
+
+// NewOpenPGP creates an OpenPGP backend that reads its keys from the key file
+// or legacy keyring directory.
+func NewOpenPGP(keyring string) *OpenPGP {
+	return &OpenPGP{
+		Keyring:    keyring,
+		Passphrase: ttyPassphrase,
+	}
+}
+
+// keys lazily reads the keyring, so commands that do not need it stay fast.
+func (o *OpenPGP) keys() (openpgp.EntityList, error) {
+	o.once.Do(func() {
+		o.entities, o.err = readKeyring(o.Keyring)
+	})
+	return o.entities, o.err
+}
+
+// Encrypt the plaintext for the recipients in the keyring.
+func (o *OpenPGP) Encrypt(ctx context.Context, recipients []string, plaintext []byte) ([]byte, error) {
+	keys, err := o.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	to := []*openpgp.Entity{}
+	for _, recipient := range recipients {
+		entity := findEntity(keys, recipient)
+		if entity == nil {
+			return nil, fmt.Errorf("no public key found for recipient '%s'", recipient)
+		}
+		to = append(to, entity)
+	}
+
+	// Like pass, do not compress secrets.
+	config := &packet.Config{
+		DefaultCompressionAlgo: packet.CompressionNone,
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := openpgp.Encrypt(buf, to, nil, nil, config)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	_, err = w.Write(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt the ciphertext with one of the private keys in the keyring.
+func (o *OpenPGP) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	keys, err := o.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := 0
+	prompt := func(candidates []openpgp.Key, symmetric bool) ([]byte, error) {
+		if len(candidates) == 0 || attempts >= passphraseAttempts {
+			return nil, errors.New("no private key available to decrypt secret")
+		}
+		attempts++
+
+		key := candidates[0]
+		pass, err := o.Passphrase(fmt.Sprintf("passphrase for key %s: ", key.PublicKey.KeyIdString()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range candidates {
+			// Wrong passphrases are retried by openpgp.ReadMessage.
+			key.PrivateKey.Decrypt(pass)
+		}
+
+		return nil, nil
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), keys, prompt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt: %s", err)
+	}
+
+	buf, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt: %s", err)
+	}
+
+	return buf, nil
+}
+
+// Keys lists the ids of the keys the recipients would be encrypted for.
+func (o *OpenPGP) Keys(ctx context.Context, recipients []string) ([]string, error) {
+	keys, err := o.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, recipient := range recipients {
+		entity := findEntity(keys, recipient)
+		if entity == nil {
+			return nil, fmt.Errorf("no public key found for recipient '%s'", recipient)
+		}
+
+		key, ok := entity.EncryptionKey(time.Now())
+		if !ok {
+			return nil, fmt.Errorf("no encryption key found for recipient '%s'", recipient)
+		}
+
+		res = append(res, key.PublicKey.KeyIdString())
+	}
+
+	return res, nil
+}
+
+// EncryptedFor lists the ids of the keys the ciphertext is encrypted for.
+func (o *OpenPGP) EncryptedFor(ctx context.Context, ciphertext []byte) ([]string, error) {
+	res := []string{}
+
+	packets := packet.NewReader(bytes.NewReader(ciphertext))
+	for {
+		p, err := packets.Next()
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret: %s", err)
+		}
+
+		key, ok := p.(*packet.EncryptedKey)
+		if !ok {
+			// The encrypted keys are all at the start of the message.
+			break
+		}
+
+		res = append(res, fmt.Sprintf("%016X", key.KeyId))
+	}
+
+	return res, nil
+}
+
+// readKeyring reads all keys from a key file or from the keyrings in a directory.
+func readKeyring(keyring string) (openpgp.EntityList, error) {
+	info, err := os.Stat(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keyring '%s'", keyring)
+	}
+
+	if !info.IsDir() {
+		return readKeyFile(keyring)
+	}
+
+	res := openpgp.EntityList{}
+	for _, name := range []string{"pubring.gpg", "secring.gpg"} {
+		filename := filepath.Join(keyring, name)
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+
+		entities, err := readKeyFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entities...)
+	}
+
+	if len(res) == 0 {
+		if _, err := os.Stat(filepath.Join(keyring, "pubring.kbx")); err == nil {
+			return nil, fmt.Errorf("keyring '%s' uses the gpg 2.1 keybox format that the openpgp backend can not read, export your keys with `gpg --export-secret-keys > keys.gpg` and set SPASS_KEYRING to that file", keyring)
+		}
+		return nil, fmt.Errorf("no keys found in keyring '%s'", keyring)
+	}
+
+	return res, nil
+}
+
+// readKeyFile reads a binary or armored key file.
+func readKeyFile(filename string) (openpgp.EntityList, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file '%s'", filename)
+	}
+
+	var entities openpgp.EntityList
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("-----BEGIN")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(buf))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(buf))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file '%s': %s", filename, err)
+	}
+
+	return entities, nil
+}
+
+// findEntity finds the key for a recipient the way gpg does, either by
+// fingerprint, (long) key id, email address or a part of the user id.
+func findEntity(entities openpgp.EntityList, recipient string) *openpgp.Entity {
+	recipient = strings.TrimSuffix(strings.TrimSpace(recipient), "!")
+	id := strings.ToUpper(strings.TrimPrefix(recipient, "0x"))
+	_, err := hex.DecodeString(id)
+	ishex := err == nil && len(id) >= 8
+
+	email := strings.ToLower(strings.Trim(recipient, "<>"))
+
+	for _, entity := range entities {
+		if ishex {
+			if strings.HasSuffix(fingerprint(entity.PrimaryKey), id) {
+				return entity
+			}
+			for _, subkey := range entity.Subkeys {
+				if strings.HasSuffix(fingerprint(subkey.PublicKey), id) {
+					return entity
+				}
+			}
+			continue
+		}
+
+		for _, identity := range entity.Identities {
+			if strings.ToLower(identity.UserId.Email) == email {
+				return entity
+			}
+			if strings.Contains(strings.ToLower(identity.Name), strings.ToLower(recipient)) {
+				return entity
+			}
+		}
+	}
+
+	return nil
+}
+
+func fingerprint(key *packet.PublicKey) string {
+	return strings.ToUpper(hex.EncodeToString(key.Fingerprint))
+}
+
+// ttyPassphrase reads a passphrase from the terminal, without echoing it.
+func ttyPassphrase(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.New("cannot read passphrase without a terminal")
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	pass, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return nil, errors.New("cannot read passphrase")
+	}
+
+	return pass, nil
+}
@@ -0,0 +1,107 @@
+package spass
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+func TestOpenPGPKeyFile(t *testing.T) {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "keys.gpg")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = entity.SerializePrivate(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	ctx := context.Background()
+	crypto := NewOpenPGP(filename)
+
+	ciphertext, err := crypto.Encrypt(ctx, []string{"test@example.com"}, []byte("hunter2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := crypto.Decrypt(ctx, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != "hunter2\n" {
+		t.Errorf("expected the plaintext, got %q", plaintext)
+	}
+
+	current, err := crypto.EncryptedFor(ctx, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wanted, err := crypto.Keys(ctx, []string{"test@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKeys(current, wanted) {
+		t.Errorf("encrypted for %v, want %v", current, wanted)
+	}
+}
+
+func TestOpenPGPKeyring(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{
+			name:  "keybox",
+			files: []string{"pubring.kbx"},
+			err:   "set SPASS_KEYRING",
+		},
+		{
+			name: "empty",
+			err:  "no keys found in keyring",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range test.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := readKeyring(dir)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestNewCryptoOpenPGP(t *testing.T) {
+	_, err := NewCrypto(&Env{SPASS_CRYPTO: "openpgp"})
+	if err == nil || !strings.Contains(err.Error(), "set SPASS_KEYRING") {
+		t.Errorf("expected an error without SPASS_KEYRING, got %v", err)
+	}
+
+	crypto, err := NewCrypto(&Env{SPASS_CRYPTO: "openpgp", SPASS_KEYRING: "keys.gpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring := crypto.(*OpenPGP).Keyring; keyring != "keys.gpg" {
+		t.Errorf("expected the keys to be read from SPASS_KEYRING, got %q", keyring)
+	}
+}
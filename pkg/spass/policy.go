@@ -0,0 +1,25 @@
+package spass
+
+import (
+	"path/filepath"
+)
+
+// PolicyFileName is the name of the files that configure how passwords are generated in a namespace.
+const PolicyFileName = ".spass-policy"
+
+// PolicyFile finds the nearest policy file for the secret or namespace, walking up
+// to the root of the store like the recipients files.
+// It returns the empty string when there is none.
+func (s *FileStore) PolicyFile(name string) string {
+	dir := filepath.Join(s.env.PASSWORD_STORE_DIR, name)
+	if !isDir(dir) {
+		dir = filepath.Dir(dir)
+	}
+
+	filename, err := findIDFile(s.env.PASSWORD_STORE_DIR, dir, PolicyFileName)
+	if err != nil {
+		return ""
+	}
+
+	return filename
+}
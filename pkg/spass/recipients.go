@@ -0,0 +1,57 @@
+package spass
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findIDFile finds the nearest recipients file with one of the names,
+// walking up from dir to the root of the store, just like pass does.
+func findIDFile(root string, dir string, names ...string) (string, error) {
+	root = filepath.Clean(root)
+	dir = filepath.Clean(dir)
+
+	for {
+		for _, name := range names {
+			filename := filepath.Join(dir, name)
+			if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+				return filename, nil
+			}
+		}
+
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			break
+		}
+
+		dir = filepath.Dir(dir)
+	}
+
+	return "", fmt.Errorf("no %s file found", strings.Join(names, " or "))
+}
+
+// readRecipients reads the recipients from a recipients file.
+// Recipients are listed one per line, comments start with a #.
+func readRecipients(filename string) ([]string, error) {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s file", filepath.Base(filename))
+	}
+
+	res := []string{}
+	for _, line := range strings.Split(string(buf), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		res = append(res, line)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no recipients in %s file", filepath.Base(filename))
+	}
+
+	return res, nil
+}
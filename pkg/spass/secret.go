@@ -2,9 +2,9 @@ package spass
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -12,12 +12,13 @@ import (
 // SecretFile implements Secret
 type SecretFile struct {
 	env      *Env
+	backend  *backend
 	filename string
 }
 
-// strip the gpg suffix of a secret
+// strip the .gpg or .age suffix of a secret
 func strip(filename string) string {
-	return strings.TrimSuffix(filename, ".gpg")
+	return strings.TrimSuffix(strings.TrimSuffix(filename, ".gpg"), ".age")
 }
 
 // Name gets the name of secret
@@ -25,6 +26,11 @@ func (s *SecretFile) FullName() string {
 	return strings.TrimPrefix(strip(s.filename), s.env.PASSWORD_STORE_DIR+"/")
 }
 
+// Filename gets the path of the file the secret is stored in
+func (s *SecretFile) Filename() string {
+	return s.filename
+}
+
 // Name gets the name of secret
 func (s *SecretFile) Name() string {
 	return filepath.Base(s.FullName())
@@ -40,38 +46,44 @@ func (s *SecretFile) Namespace() string {
 }
 
 func (s *SecretFile) decrypt(ctx context.Context) ([]byte, error) {
-	cmd := exec.CommandContext(ctx, "gpg", "--decrypt", s.filename)
-	return cmd.Output()
+	buf, err := os.ReadFile(s.filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read secret '%s'", s.FullName())
+	}
+
+	return s.backend.crypto.Decrypt(ctx, buf)
 }
 
-func (s *SecretFile) encrypt(ctx context.Context, keyid string, content string) ([]byte, error) {
-	cmd := exec.CommandContext(ctx, "gpg", "--recipient", keyid, "--encrypt")
-	cmd.Stdin = strings.NewReader(content)
-	return cmd.Output()
+func (s *SecretFile) encrypt(ctx context.Context, recipients []string, content string) ([]byte, error) {
+	return s.backend.crypto.Encrypt(ctx, recipients, []byte(content))
 }
 
-func (s *SecretFile) keyid() (string, error) {
-	dir := filepath.Dir(s.filename)
-	idFile := filepath.Join(dir, ".gpg-id")
-	buf, err := os.ReadFile(idFile)
+// recipients finds the recipients the secret should be encrypted for.
+func (s *SecretFile) recipients() ([]string, error) {
+	idFile, err := findIDFile(s.env.PASSWORD_STORE_DIR, filepath.Dir(s.filename), s.backend.idfile)
 	if err != nil {
-		return "", fmt.Errorf("cannot read .gpg-id file for secret")
+		return nil, fmt.Errorf("cannot find %s file for secret '%s'", s.backend.idfile, s.FullName())
 	}
 
-	return strings.TrimRight(string(buf), "\n"), nil
+	return readRecipients(idFile)
 }
 
 func (s *SecretFile) Write(ctx context.Context, content string) error {
-	keyid, err := s.keyid()
+	recipients, err := s.recipients()
 	if err != nil {
 		return err
 	}
 
-	buf, err := s.encrypt(ctx, keyid, content)
+	buf, err := s.encrypt(ctx, recipients, content)
 	if err != nil {
 		return err
 	}
 
+	err = os.MkdirAll(filepath.Dir(s.filename), 0700)
+	if err != nil {
+		return fmt.Errorf("could not create namespace for secret '%s'", s.FullName())
+	}
+
 	err = os.WriteFile(s.filename, buf, 0644)
 	if err != nil {
 		return fmt.Errorf("could not write secret '%s'", s.FullName())
@@ -80,6 +92,72 @@ func (s *SecretFile) Write(ctx context.Context, content string) error {
 	return nil
 }
 
+// Reencrypt encrypts the secret for the current recipients.
+// It returns false when the secret was already encrypted for exactly those recipients.
+func (s *SecretFile) Reencrypt(ctx context.Context) (bool, error) {
+	recipients, err := s.recipients()
+	if err != nil {
+		return false, err
+	}
+
+	if lister, ok := s.backend.crypto.(KeyLister); ok {
+		buf, err := os.ReadFile(s.filename)
+		if err != nil {
+			return false, fmt.Errorf("could not read secret '%s'", s.FullName())
+		}
+
+		current, err := lister.EncryptedFor(ctx, buf)
+		if err != nil {
+			return false, err
+		}
+
+		wanted, err := lister.Keys(ctx, recipients)
+		if err != nil {
+			return false, err
+		}
+
+		if sameKeys(current, wanted) {
+			return false, nil
+		}
+	}
+
+	body, err := s.Body(ctx)
+	if err != nil {
+		return false, fmt.Errorf("could not decrypt secret '%s'", s.FullName())
+	}
+
+	buf, err := s.encrypt(ctx, recipients, body)
+	if err != nil {
+		return false, err
+	}
+
+	err = os.WriteFile(s.filename, buf, 0644)
+	if err != nil {
+		return false, fmt.Errorf("could not write secret '%s'", s.FullName())
+	}
+
+	return true, nil
+}
+
+// sameKeys checks if both lists contain the same key ids.
+func sameKeys(a []string, b []string) bool {
+	set := map[string]bool{}
+	for _, id := range a {
+		set[strings.ToUpper(id)] = true
+	}
+
+	other := map[string]bool{}
+	for _, id := range b {
+		id = strings.ToUpper(id)
+		if !set[id] {
+			return false
+		}
+		other[id] = true
+	}
+
+	return len(set) == len(other)
+}
+
 // Name gets the name of secret
 func (s *SecretFile) Body(ctx context.Context) (string, error) {
 	buf, err := s.decrypt(ctx)
@@ -90,25 +168,24 @@ func (s *SecretFile) Body(ctx context.Context) (string, error) {
 	return string(buf), nil
 }
 
-// Name gets the name of secret
-func (s *SecretFile) Password(ctx context.Context) (string, error) {
-	body, err := s.Body(ctx)
+// Decrypt decrypts another version of the secret, for instance one from its history.
+func (s *SecretFile) Decrypt(ctx context.Context, ciphertext []byte) (string, error) {
+	buf, err := s.backend.crypto.Decrypt(ctx, ciphertext)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
-	lines := strings.SplitN(body, "\n", 2)
-	pass := lines[0]
-
-	if pass == "" {
-		return "", fmt.Errorf("no password set for secret '%s'", s.FullName())
-	}
+	return string(buf), nil
+}
 
-	if strings.HasPrefix(pass, "otpauth://totp/") {
-		return "", fmt.Errorf("no password set for secret '%s'", s.FullName())
+// Name gets the name of secret
+func (s *SecretFile) Password(ctx context.Context) (string, error) {
+	body, err := s.Body(ctx)
+	if err != nil {
+		return "", err
 	}
 
-	return pass, nil
+	return password(s.FullName(), body)
 }
 
 // Set password
@@ -121,16 +198,11 @@ func (s *SecretFile) SetPassword(ctx context.Context, password string) error {
 		}
 	}
 
-	lines := strings.SplitN(body, "\n", 2)
-
-	lines[0] = password
-	body = strings.Join(lines, "\n")
-
-	return s.Write(ctx, body)
+	return s.Write(ctx, withPassword(body, password))
 }
 
 // Remove wipes and removes the secret
-func (s *SecretFile) Remove() error {
+func (s *SecretFile) Remove(ctx context.Context) error {
 	f, err := os.OpenFile(s.filename, os.O_RDWR, 0644)
 	if err != nil {
 		return fmt.Errorf("could not open secret '%s'", s.FullName())
@@ -173,6 +245,38 @@ func (s *SecretFile) Pairs(ctx context.Context) ([]*Pair, error) {
 		return nil, err
 	}
 
+	return pairs(body), nil
+}
+
+// ErrNoPassword is returned for secrets without a password, like otp secrets.
+var ErrNoPassword = errors.New("no password set")
+
+// password gets the password from the body of a secret
+func password(name string, body string) (string, error) {
+	lines := strings.SplitN(body, "\n", 2)
+	pass := lines[0]
+
+	if pass == "" {
+		return "", fmt.Errorf("%w for secret '%s'", ErrNoPassword, name)
+	}
+
+	if strings.HasPrefix(pass, "otpauth://totp/") || strings.HasPrefix(pass, "otpauth://hotp/") {
+		return "", fmt.Errorf("%w for secret '%s'", ErrNoPassword, name)
+	}
+
+	return pass, nil
+}
+
+// withPassword replaces the password in the body of a secret
+func withPassword(body string, password string) string {
+	lines := strings.SplitN(body, "\n", 2)
+
+	lines[0] = password
+	return strings.Join(lines, "\n")
+}
+
+// pairs gets the pairs from the body of a secret
+func pairs(body string) []*Pair {
 	lines := strings.Split(body, "\n")
 	res := make([]*Pair, 0, len(lines))
 	for i, line := range lines {
@@ -186,7 +290,7 @@ func (s *SecretFile) Pairs(ctx context.Context) ([]*Pair, error) {
 		res = append(res, parse(line))
 	}
 
-	return res, nil
+	return res
 }
 
 func parse(line string) *Pair {
@@ -0,0 +1,36 @@
+package spass
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+		err  bool
+	}{
+		{body: "hunter2\nusername: john\n", want: "hunter2"},
+		{body: "hunter2", want: "hunter2"},
+		{body: "\nusername: john\n", err: true},
+		{body: "otpauth://totp/example?secret=JBSWY3DPEHPK3PXP\n", err: true},
+		{body: "otpauth://hotp/example?secret=JBSWY3DPEHPK3PXP&counter=1\n", err: true},
+	}
+
+	for _, test := range tests {
+		got, err := password("test", test.body)
+		if test.err {
+			if !errors.Is(err, ErrNoPassword) {
+				t.Errorf("password(%q): expected ErrNoPassword, got %q, %v", test.body, got, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("password(%q): %s", test.body, err)
+		} else if got != test.want {
+			t.Errorf("password(%q) = %q, want %q", test.body, got, test.want)
+		}
+	}
+}
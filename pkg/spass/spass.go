@@ -17,7 +17,7 @@ type Secret interface {
 	Namespace() string
 
 	// The name, including the namespace.
-	Fullname() string
+	FullName() string
 
 	// Get the password in the secret
 	Password(ctx context.Context) (string, error)
@@ -25,6 +25,9 @@ type Secret interface {
 	// The full body of the secret
 	Body(ctx context.Context) (string, error)
 
+	// Write replaces the full body of the secret
+	Write(ctx context.Context, content string) error
+
 	// SetPassword sets the password of the secret
 	SetPassword(ctx context.Context, pass string) error
 
@@ -38,11 +41,22 @@ type Secret interface {
 type Store interface {
 	// List all secrets in a store.
 	// If namespace is not the empty string, it will filter by the provided namespace.
-	List(ctx context.Context, namepace string) ([]Secret, error)
+	List(ctx context.Context, namespace string) ([]Secret, error)
 
 	// Get the secret by name.
 	Secret(ctx context.Context, name string) (Secret, error)
 
-	// Create a new secret.
-	NewSecret(ctx context.Context) (Secret, error)
+	// Create a new secret with the name.
+	// The secret is only stored once it is written.
+	NewSecret(ctx context.Context, name string) (Secret, error)
 }
+
+var (
+	_ Store  = (*FileStore)(nil)
+	_ Secret = (*SecretFile)(nil)
+	_ Store  = (*MemoryStore)(nil)
+	_ Secret = (*MemorySecret)(nil)
+
+	_ Crypto    = (*TestCrypto)(nil)
+	_ KeyLister = (*TestCrypto)(nil)
+)
@@ -6,39 +6,118 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // FileStore implements Store
 type FileStore struct {
-	env *Env
+	env      *Env
+	backends []*backend
 }
 
 // NewFileStore creates a new FileStore
-func NewFileStore(env *Env) *FileStore {
+func NewFileStore(env *Env) (*FileStore, error) {
+	crypto, err := NewCrypto(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFileStore(env, crypto, NewAge(env.PASSAGE_IDENTITIES_FILE)), nil
+}
+
+// NewFileStoreWithCrypto creates a new FileStore that uses crypto for all
+// its secrets, for instance a TestCrypto.
+func NewFileStoreWithCrypto(env *Env, crypto Crypto) *FileStore {
+	return newFileStore(env, crypto, crypto)
+}
+
+func newFileStore(env *Env, gpg Crypto, age Crypto) *FileStore {
+	// The first backend is the default for new secrets.
+	backends := []*backend{
+		{
+			ext:    ".gpg",
+			idfile: ".gpg-id",
+			crypto: gpg,
+		},
+		{
+			ext:    ".age",
+			idfile: ".age-recipients",
+			crypto: age,
+		},
+	}
+
 	return &FileStore{
-		env: env,
+		env:      env,
+		backends: backends,
 	}
 }
 
-// List the secrets in the store
-func (s *FileStore) List(ctx context.Context, namespace string) ([]*SecretFile, error) {
-	res := []*SecretFile{}
+// backendFor finds the backend that stored the secret file.
+func (s *FileStore) backendFor(filename string) *backend {
+	for _, b := range s.backends {
+		if filepath.Ext(filename) == b.ext {
+			return b
+		}
+	}
+	return nil
+}
+
+// backendIn finds the backend to use for new secrets in the directory,
+// based on the nearest recipients file.
+func (s *FileStore) backendIn(dir string) *backend {
+	names := []string{}
+	for _, b := range s.backends {
+		names = append(names, b.idfile)
+	}
 
-	err := filepath.Walk(s.env.PASSWORD_STORE_DIR, func(pth string, info fs.FileInfo, err error) error {
+	idFile, err := findIDFile(s.env.PASSWORD_STORE_DIR, dir, names...)
+	if err != nil {
+		return s.backends[0]
+	}
+
+	for _, b := range s.backends {
+		if filepath.Base(idFile) == b.idfile {
+			return b
+		}
+	}
+	return s.backends[0]
+}
+
+// List the secrets in the store.
+// If namespace is not empty, only the secrets in that namespace are listed.
+func (s *FileStore) List(ctx context.Context, namespace string) ([]Secret, error) {
+	res := []Secret{}
+
+	dir := filepath.Join(s.env.PASSWORD_STORE_DIR, namespace)
+	if namespace != "" && !isDir(dir) {
+		return nil, fmt.Errorf("no namespace found named '%s'", namespace)
+	}
+
+	err := filepath.Walk(dir, func(pth string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Skip hidden files and directories, like .git
+		if pth != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if info.IsDir() {
 			return nil
 		}
 
-		if info.Name() == ".gpg-id" {
+		backend := s.backendFor(pth)
+		if backend == nil {
 			return nil
 		}
 
 		res = append(res, &SecretFile{
 			env:      s.env,
+			backend:  backend,
 			filename: pth,
 		})
 
@@ -53,43 +132,73 @@ func (s *FileStore) List(ctx context.Context, namespace string) ([]*SecretFile,
 }
 
 // Get a secret by name.
-func (s *FileStore) Secret(ctx context.Context, name string) (*SecretFile, error) {
-	filename := filepath.Join(s.env.PASSWORD_STORE_DIR, name) + ".gpg"
-
-	notfound := fmt.Errorf("no secret found named '%s'", name)
-
-	f, err := os.Open(filename)
+func (s *FileStore) Secret(ctx context.Context, name string) (Secret, error) {
+	secret, err := s.secret(name)
 	if err != nil {
-		return nil, notfound
+		return nil, err
 	}
+	return secret, nil
+}
 
-	info, err := f.Stat()
-	if err != nil {
-		return nil, notfound
-	}
+func (s *FileStore) secret(name string) (*SecretFile, error) {
+	for _, backend := range s.backends {
+		filename := filepath.Join(s.env.PASSWORD_STORE_DIR, name) + backend.ext
 
-	if info.IsDir() {
-		return nil, notfound
-	}
+		info, err := os.Stat(filename)
+		if err != nil || info.IsDir() {
+			continue
+		}
 
-	secret := &SecretFile{
-		env:      s.env,
-		filename: filename,
+		secret := &SecretFile{
+			env:      s.env,
+			backend:  backend,
+			filename: filename,
+		}
+
+		return secret, nil
 	}
 
-	return secret, nil
+	return nil, fmt.Errorf("no secret found named '%s'", name)
 }
 
 // NewSecret returns a new secret.
-func (s *FileStore) NewSecret(ctx context.Context, name string) (*SecretFile, error) {
-	filename := filepath.Join(s.env.PASSWORD_STORE_DIR, name) + ".gpg"
+func (s *FileStore) NewSecret(ctx context.Context, name string) (Secret, error) {
+	return s.newSecret(name), nil
+}
+
+func (s *FileStore) newSecret(name string) *SecretFile {
+	base := filepath.Join(s.env.PASSWORD_STORE_DIR, name)
+	backend := s.backendIn(filepath.Dir(base))
 
 	// TODO: check if file exists?
 
 	secret := &SecretFile{
 		env:      s.env,
-		filename: filename,
+		backend:  backend,
+		filename: base + backend.ext,
 	}
 
-	return secret, nil
+	return secret
+}
+
+// Init sets the gpg recipients for the namespace by writing its .gpg-id file.
+func (s *FileStore) Init(ctx context.Context, namespace string, recipients []string) error {
+	dir := filepath.Join(s.env.PASSWORD_STORE_DIR, namespace)
+
+	if _, err := os.Stat(filepath.Join(dir, ".age-recipients")); err == nil {
+		return fmt.Errorf("namespace '%s' uses age, edit its .age-recipients file instead", namespace)
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("could not create namespace '%s'", namespace)
+	}
+
+	content := strings.Join(recipients, "\n") + "\n"
+	err = os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("could not write .gpg-id file for namespace '%s'", namespace)
+	}
+
+	return nil
 }
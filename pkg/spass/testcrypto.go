@@ -0,0 +1,89 @@
+package spass
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const testCryptoHeader = "spass-test-crypto "
+
+// TestCrypto is a deterministic Crypto for tests.
+//
+// It does not encrypt anything, never use it for real secrets.
+// The recipients are recorded in the ciphertext, so it also implements KeyLister.
+type TestCrypto struct {
+	// The identities that can decrypt secrets.
+	// When empty, all secrets can be decrypted.
+	Identities []string
+}
+
+// Encrypt encodes the plaintext together with the recipients.
+func (c *TestCrypto) Encrypt(ctx context.Context, recipients []string, plaintext []byte) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(testCryptoHeader)
+	buf.WriteString(strings.Join(recipients, ","))
+	buf.WriteString("\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(plaintext))
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt decodes the plaintext, if one of the identities is a recipient.
+func (c *TestCrypto) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	recipients, body, err := c.parse(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Identities) > 0 && !intersects(c.Identities, recipients) {
+		return nil, errors.New("could not decrypt: no matching identity")
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt: %s", err)
+	}
+
+	return buf, nil
+}
+
+// Keys returns the recipients themselves.
+func (c *TestCrypto) Keys(ctx context.Context, recipients []string) ([]string, error) {
+	return recipients, nil
+}
+
+// EncryptedFor returns the recipients the ciphertext was encoded for.
+func (c *TestCrypto) EncryptedFor(ctx context.Context, ciphertext []byte) ([]string, error) {
+	recipients, _, err := c.parse(ciphertext)
+	return recipients, err
+}
+
+func (c *TestCrypto) parse(ciphertext []byte) ([]string, string, error) {
+	header, body, ok := strings.Cut(string(ciphertext), "\n")
+	if !ok || !strings.HasPrefix(header, testCryptoHeader) {
+		return nil, "", errors.New("could not decrypt: invalid test ciphertext")
+	}
+
+	recipients := strings.Split(strings.TrimPrefix(header, testCryptoHeader), ",")
+	return recipients, strings.TrimSpace(body), nil
+}
+
+func intersects(a []string, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
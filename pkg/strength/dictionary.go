@@ -0,0 +1,78 @@
+package strength
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// The frequency lists of zxcvbn (https://github.com/dropbox/zxcvbn), ordered from
+// the most to the least common word.
+var (
+	//go:embed passwords.txt
+	passwordsList string
+
+	//go:embed english.txt
+	englishList string
+
+	//go:embed male_names.txt
+	maleNamesList string
+
+	//go:embed female_names.txt
+	femaleNamesList string
+
+	//go:embed surnames.txt
+	surnamesList string
+)
+
+// dictionary maps lowercase words to their rank in a frequency list, starting at 1.
+type dictionary struct {
+	name  string
+	ranks map[string]int
+
+	// The length of the longest word.
+	longest int
+}
+
+var (
+	dictionariesOnce sync.Once
+	dictionaries     []*dictionary
+)
+
+// frequencyLists returns the embedded dictionaries, they are only parsed once.
+func frequencyLists() []*dictionary {
+	dictionariesOnce.Do(func() {
+		dictionaries = []*dictionary{
+			newDictionary("passwords", strings.Split(passwordsList, "\n")),
+			newDictionary("english", strings.Split(englishList, "\n")),
+			newDictionary("male names", strings.Split(maleNamesList, "\n")),
+			newDictionary("female names", strings.Split(femaleNamesList, "\n")),
+			newDictionary("surnames", strings.Split(surnamesList, "\n")),
+		}
+	})
+	return dictionaries
+}
+
+func newDictionary(name string, words []string) *dictionary {
+	d := &dictionary{
+		name:  name,
+		ranks: make(map[string]int, len(words)),
+	}
+
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+
+		if _, ok := d.ranks[word]; !ok {
+			d.ranks[word] = len(d.ranks) + 1
+		}
+
+		if n := len([]rune(word)); n > d.longest {
+			d.longest = n
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,111 @@
+package strength
+
+import (
+	"strings"
+)
+
+// The keyboard layouts that are checked for patterns like qwerty or zxcvbn.
+// Every key lists its unshifted character first.
+const (
+	qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+
+	dvorakLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}
+    '" ,< .> pP yY fF gG cC rR lL /? =+ \|
+     aA oO eE uU iI dD hH tT nN sS -_
+      ;: qQ jJ kK xX bB mM wW vV zZ
+`
+
+	keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+
+	macKeypadLayout = `
+  = / *
+7 8 9 -
+4 5 6 +
+1 2 3
+  0 .
+`
+)
+
+// graph maps every character on a keyboard to the keys around it, in a fixed order
+// of directions. Missing neighbours are empty strings.
+type graph struct {
+	name string
+	keys map[rune][]string
+
+	// The number of keys and the average number of neighbours of a key.
+	starts float64
+	degree float64
+}
+
+var graphs = []*graph{
+	newGraph("qwerty", qwertyLayout, true),
+	newGraph("dvorak", dvorakLayout, true),
+	newGraph("keypad", keypadLayout, false),
+	newGraph("mac keypad", macKeypadLayout, false),
+}
+
+// newGraph builds the adjacency graph of a keyboard layout, like zxcvbn does.
+// The rows of slanted keyboards are shifted to the right, those of keypads are aligned.
+func newGraph(name string, layout string, slanted bool) *graph {
+	type coord struct{ x, y int }
+
+	lines := strings.Split(layout, "\n")
+	unit := len(strings.Fields(layout)[0]) + 1
+
+	positions := map[coord]string{}
+	for y, line := range lines {
+		slant := 0
+		if slanted {
+			slant = y - 1
+		}
+
+		for _, token := range strings.Fields(line) {
+			x := (strings.Index(line, token) - slant) / unit
+			positions[coord{x, y}] = token
+		}
+	}
+
+	neighbours := func(x, y int) []coord {
+		if slanted {
+			return []coord{{x - 1, y}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y + 1}}
+		}
+		return []coord{{x - 1, y}, {x - 1, y - 1}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}, {x - 1, y + 1}}
+	}
+
+	g := &graph{
+		name: name,
+		keys: map[rune][]string{},
+	}
+
+	edges := 0
+	for c, token := range positions {
+		adjacent := []string{}
+		for _, n := range neighbours(c.x, c.y) {
+			adjacent = append(adjacent, positions[n])
+			if positions[n] != "" {
+				edges++
+			}
+		}
+
+		for _, r := range token {
+			g.keys[r] = adjacent
+		}
+	}
+
+	g.starts = float64(len(g.keys))
+	g.degree = float64(edges) / float64(len(positions))
+
+	return g
+}
@@ -0,0 +1,554 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// The number of years that are considered for dates and years.
+const numYears = 119
+
+// Match is a part of a password that follows a pattern an attacker would try.
+type Match struct {
+	// The name of the pattern, like dictionary, spatial, repeat, sequence, digits, year, date or bruteforce.
+	Pattern string
+
+	// The first and last character of the match in the password.
+	I, J int
+
+	// The matched part of the password.
+	Token string
+
+	// The number of bits needed to guess the token with the pattern.
+	Entropy float64
+
+	// The dictionary or keyboard the token was found in.
+	Dictionary string
+}
+
+// l33t lists the characters that are commonly substituted for letters.
+var l33t = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// sequences lists the characters that are commonly typed in order, like abc or 321.
+var sequences = []string{
+	"abcdefghijklmnopqrstuvwxyz",
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"0123456789",
+}
+
+// matches finds all patterns in the password.
+func matches(password []rune, dicts []*dictionary) []*Match {
+	res := []*Match{}
+	res = append(res, dictionaryMatches(password, dicts)...)
+	res = append(res, reversedMatches(password, dicts)...)
+	res = append(res, l33tMatches(password, dicts)...)
+	res = append(res, spatialMatches(password)...)
+	res = append(res, repeatMatches(password)...)
+	res = append(res, sequenceMatches(password)...)
+	res = append(res, digitsMatches(password)...)
+	res = append(res, yearMatches(password)...)
+	res = append(res, dateMatches(password)...)
+	return res
+}
+
+// dictionaryMatches finds the words of the dictionaries in the password.
+func dictionaryMatches(password []rune, dicts []*dictionary) []*Match {
+	lower := []rune(strings.ToLower(string(password)))
+	if len(lower) != len(password) {
+		return nil
+	}
+
+	res := []*Match{}
+	for _, d := range dicts {
+		for i := range password {
+			for j := i; j < len(password) && j-i < d.longest; j++ {
+				rank, ok := d.ranks[string(lower[i:j+1])]
+				if !ok {
+					continue
+				}
+
+				token := string(password[i : j+1])
+				res = append(res, &Match{
+					Pattern:    "dictionary",
+					I:          i,
+					J:          j,
+					Token:      token,
+					Entropy:    rankEntropy(rank) + uppercaseEntropy(token),
+					Dictionary: d.name,
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// rankEntropy is the entropy of a word with the rank. Even the most common word
+// takes a guess, otherwise repeating it would not make a password stronger.
+func rankEntropy(rank int) float64 {
+	return math.Max(1, math.Log2(float64(rank)))
+}
+
+// reversedMatches finds the words of the dictionaries written backwards, like drowssap.
+func reversedMatches(password []rune, dicts []*dictionary) []*Match {
+	reversed := make([]rune, len(password))
+	for i, c := range password {
+		reversed[len(password)-1-i] = c
+	}
+
+	res := []*Match{}
+	for _, m := range dictionaryMatches(reversed, dicts) {
+		if m.J == m.I {
+			continue
+		}
+
+		i, j := len(password)-1-m.J, len(password)-1-m.I
+		res = append(res, &Match{
+			Pattern:    "dictionary",
+			I:          i,
+			J:          j,
+			Token:      string(password[i : j+1]),
+			Entropy:    m.Entropy + 1,
+			Dictionary: m.Dictionary,
+		})
+	}
+
+	return res
+}
+
+// l33tMatches finds the words of the dictionaries with l33t substitutions, like p4ssw0rd.
+func l33tMatches(password []rune, dicts []*dictionary) []*Match {
+	res := []*Match{}
+	for _, sub := range l33tSubs(password) {
+		translated := make([]rune, len(password))
+		for i, c := range password {
+			translated[i] = c
+			if letter, ok := sub[c]; ok {
+				translated[i] = letter
+			}
+		}
+
+		for _, m := range dictionaryMatches(translated, dicts) {
+			token := password[m.I : m.J+1]
+			if m.I == m.J {
+				continue
+			}
+
+			// Only keep the substitutions that are used in the token.
+			used := map[rune]rune{}
+			for _, c := range token {
+				if letter, ok := sub[c]; ok {
+					used[c] = letter
+				}
+			}
+			if len(used) == 0 {
+				continue
+			}
+
+			res = append(res, &Match{
+				Pattern:    "dictionary",
+				I:          m.I,
+				J:          m.J,
+				Token:      string(token),
+				Entropy:    m.Entropy + l33tEntropy(token, used),
+				Dictionary: m.Dictionary,
+			})
+		}
+	}
+
+	return res
+}
+
+// l33tSubs returns every way to replace the l33t characters in the password with a letter.
+func l33tSubs(password []rune) []map[rune]rune {
+	options := map[rune][]rune{}
+	for letter, subs := range l33t {
+		for _, c := range subs {
+			if strings.ContainsRune(string(password), c) {
+				options[c] = append(options[c], letter)
+			}
+		}
+	}
+
+	res := []map[rune]rune{{}}
+	for c, letters := range options {
+		next := []map[rune]rune{}
+		for _, sub := range res {
+			for _, letter := range letters {
+				s := map[rune]rune{c: letter}
+				for k, v := range sub {
+					s[k] = v
+				}
+				next = append(next, s)
+			}
+		}
+		res = next
+	}
+
+	if len(res) == 1 && len(res[0]) == 0 {
+		return nil
+	}
+
+	return res
+}
+
+// spatialMatches finds runs of neighbouring keys, like qwerty or 7896.
+func spatialMatches(password []rune) []*Match {
+	res := []*Match{}
+	for _, g := range graphs {
+		i := 0
+		for i < len(password)-1 {
+			j := i + 1
+			last := -1
+			turns := 0
+			shifted := 0
+
+			for {
+				found := false
+				if j < len(password) {
+					for direction, key := range g.keys[password[j-1]] {
+						k := strings.IndexRune(key, password[j])
+						if key == "" || k < 0 {
+							continue
+						}
+
+						found = true
+						if k == 1 {
+							shifted++
+						}
+						if direction != last {
+							turns++
+							last = direction
+						}
+						break
+					}
+				}
+
+				if found {
+					j++
+					continue
+				}
+
+				// Two keys next to each other are too common to count.
+				if j-i > 2 {
+					token := password[i:j]
+					res = append(res, &Match{
+						Pattern:    "spatial",
+						I:          i,
+						J:          j - 1,
+						Token:      string(token),
+						Entropy:    spatialEntropy(g, len(token), turns, shifted),
+						Dictionary: g.name,
+					})
+				}
+
+				i = j
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// repeatMatches finds characters that are repeated, like aaa.
+func repeatMatches(password []rune) []*Match {
+	res := []*Match{}
+	for i := 0; i < len(password); {
+		j := i + 1
+		for j < len(password) && password[j] == password[i] {
+			j++
+		}
+
+		if j-i > 2 {
+			token := password[i:j]
+			res = append(res, &Match{
+				Pattern: "repeat",
+				I:       i,
+				J:       j - 1,
+				Token:   string(token),
+				Entropy: math.Log2(cardinality(token) * float64(len(token))),
+			})
+		}
+
+		i = j
+	}
+
+	return res
+}
+
+// sequenceMatches finds characters that are typed in order, like abcd or 9876.
+func sequenceMatches(password []rune) []*Match {
+	res := []*Match{}
+	for i := 0; i < len(password)-1; {
+		j := i + 1
+
+		seq := ""
+		direction := 0
+		for _, s := range sequences {
+			a := strings.IndexRune(s, password[i])
+			b := strings.IndexRune(s, password[j])
+			if a >= 0 && b >= 0 && (b-a == 1 || b-a == -1) {
+				seq = s
+				direction = b - a
+				break
+			}
+		}
+
+		if seq == "" {
+			i = j
+			continue
+		}
+
+		for j < len(password) {
+			k := strings.IndexRune(seq, password[j])
+			if k < 0 || k-strings.IndexRune(seq, password[j-1]) != direction {
+				break
+			}
+			j++
+		}
+
+		if j-i > 2 {
+			token := password[i:j]
+			res = append(res, &Match{
+				Pattern: "sequence",
+				I:       i,
+				J:       j - 1,
+				Token:   string(token),
+				Entropy: sequenceEntropy(token, direction > 0),
+			})
+		}
+
+		i = j
+	}
+
+	return res
+}
+
+// digitsMatches finds runs of three or more digits.
+func digitsMatches(password []rune) []*Match {
+	res := []*Match{}
+	for i := 0; i < len(password); {
+		j := i
+		for j < len(password) && isDigit(password[j]) {
+			j++
+		}
+
+		if j-i > 2 {
+			res = append(res, &Match{
+				Pattern: "digits",
+				I:       i,
+				J:       j - 1,
+				Token:   string(password[i:j]),
+				Entropy: float64(j-i) * math.Log2(10),
+			})
+		}
+
+		i = j + 1
+	}
+
+	return res
+}
+
+// yearMatches finds recent years, like 1987 or 2024.
+func yearMatches(password []rune) []*Match {
+	res := []*Match{}
+	for i := 0; i+4 <= len(password); i++ {
+		token := string(password[i : i+4])
+		if !allDigits(password[i:i+4]) || !(strings.HasPrefix(token, "19") || strings.HasPrefix(token, "20")) {
+			continue
+		}
+
+		res = append(res, &Match{
+			Pattern: "year",
+			I:       i,
+			J:       i + 3,
+			Token:   token,
+			Entropy: math.Log2(numYears),
+		})
+	}
+
+	return res
+}
+
+// dateMatches finds dates, like 13051987 or 13/5/87.
+func dateMatches(password []rune) []*Match {
+	res := []*Match{}
+	for i := range password {
+		for j := i + 3; j < len(password) && j < i+10; j++ {
+			token := password[i : j+1]
+
+			parts, separator := dateParts(token)
+			if parts == nil {
+				continue
+			}
+
+			twoDigits, ok := validDate(parts)
+			if !ok {
+				continue
+			}
+
+			entropy := math.Log2(31 * 12 * numYears)
+			if twoDigits {
+				entropy = math.Log2(31 * 12 * 100)
+			}
+			if separator {
+				entropy += 2
+			}
+
+			res = append(res, &Match{
+				Pattern: "date",
+				I:       i,
+				J:       j,
+				Token:   string(token),
+				Entropy: entropy,
+			})
+		}
+	}
+
+	return res
+}
+
+// dateParts splits a token into the possible day, month and year parts of a date.
+// It returns every split for dates without separators.
+func dateParts(token []rune) ([][3]string, bool) {
+	if allDigits(token) {
+		if len(token) > 8 {
+			return nil, false
+		}
+
+		res := [][3]string{}
+		for k := 1; k < len(token)-1; k++ {
+			for l := k + 1; l < len(token); l++ {
+				res = append(res, [3]string{string(token[:k]), string(token[k:l]), string(token[l:])})
+			}
+		}
+		return res, false
+	}
+
+	// Dates with separators use the same separator twice, like 13-5-1987.
+	fields := strings.FieldsFunc(string(token), func(c rune) bool {
+		return strings.ContainsRune(" /\\_.-", c)
+	})
+	if len(fields) != 3 || !allDigits([]rune(strings.Join(fields, ""))) {
+		return nil, false
+	}
+
+	separators := strings.Map(func(c rune) rune {
+		if isDigit(c) {
+			return -1
+		}
+		return c
+	}, string(token))
+	if len(separators) != 2 || separators[0] != separators[1] {
+		return nil, false
+	}
+
+	return [][3]string{{fields[0], fields[1], fields[2]}}, true
+}
+
+// validDate checks if any of the splits is a valid date, with the year first or last.
+// It reports if the year has two digits.
+func validDate(splits [][3]string) (bool, bool) {
+	for _, parts := range splits {
+		orders := [][3]string{
+			{parts[0], parts[1], parts[2]},
+			{parts[0], parts[2], parts[1]},
+			{parts[2], parts[0], parts[1]},
+			{parts[2], parts[1], parts[0]},
+		}
+
+		for _, o := range orders {
+			year, month, day := o[0], o[1], o[2]
+			if len(month) > 2 || len(day) > 2 || (len(year) != 2 && len(year) != 4) {
+				continue
+			}
+
+			y, m, d := atoi(year), atoi(month), atoi(day)
+			if m < 1 || m > 12 || d < 1 || d > 31 {
+				continue
+			}
+
+			if len(year) == 4 && (y < 1900 || y > 2050) {
+				continue
+			}
+
+			return len(year) == 2, true
+		}
+	}
+
+	return false, false
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func allDigits(token []rune) bool {
+	for _, c := range token {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return len(token) > 0
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = 10*n + int(c-'0')
+	}
+	return n
+}
+
+// cardinality returns the number of characters an attacker would need to try for
+// every character of the token.
+func cardinality(token []rune) float64 {
+	var lower, upper, digits, symbols, other bool
+	for _, c := range token {
+		switch {
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case isDigit(c):
+			digits = true
+		case c < unicode.MaxASCII:
+			symbols = true
+		default:
+			other = true
+		}
+	}
+
+	n := 0.0
+	if lower {
+		n += 26
+	}
+	if upper {
+		n += 26
+	}
+	if digits {
+		n += 10
+	}
+	if symbols {
+		n += 33
+	}
+	if other {
+		n += 100
+	}
+	return n
+}
@@ -0,0 +1,327 @@
+// Package strength estimates how hard passwords are to guess, like zxcvbn does.
+//
+// A password is split into the parts an attacker would guess with the least effort,
+// like dictionary words (also reversed or in l33t), keyboard patterns, repeats,
+// sequences, dates and years. The remaining characters are brute forced.
+package strength
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The time it takes an attacker to try a single guess, for an offline attack
+// on a slow hash by many machines at the same time.
+const guessTime = 100 * time.Microsecond
+
+// Result is the estimated strength of a password.
+type Result struct {
+	// The number of bits of entropy an attacker needs to guess.
+	Entropy float64
+
+	// The time it takes to guess the password, on average.
+	CrackTime time.Duration
+
+	// From 0 to 4, passwords with a score below 3 are weak.
+	Score int
+
+	// The parts of the password an attacker would guess.
+	Matches []*Match
+}
+
+// Weak reports if the password is easy to guess.
+func (r *Result) Weak() bool {
+	return r.Score < 3
+}
+
+// CrackTimeDisplay formats the crack time for humans, like "3 hours" or "centuries".
+func (r *Result) CrackTimeDisplay() string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 31 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	}
+
+	if r.CrackTime < time.Second {
+		return "instant"
+	}
+
+	if r.CrackTime >= 100*units[0].size {
+		return "centuries"
+	}
+
+	for _, unit := range units {
+		if n := int(r.CrackTime / unit.size); n > 0 {
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+
+	return "instant"
+}
+
+// Patterns returns the names of the patterns found in the password, without the brute forced parts.
+func (r *Result) Patterns() []string {
+	seen := map[string]bool{}
+	res := []string{}
+	for _, m := range r.Matches {
+		name := m.Pattern
+		if m.Dictionary != "" {
+			name = m.Dictionary
+		}
+
+		if m.Pattern == "bruteforce" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		res = append(res, name)
+	}
+	return res
+}
+
+// Estimate estimates the strength of the password.
+// Words that the password should not be based on, like the name of the secret, can be passed as well.
+func Estimate(password string, words ...string) *Result {
+	dicts := frequencyLists()
+
+	inputs := []string{}
+	for _, word := range words {
+		for _, w := range strings.FieldsFunc(word, isSeparator) {
+			inputs = append(inputs, w)
+		}
+	}
+	if len(inputs) > 0 {
+		dicts = append([]*dictionary{newDictionary("user inputs", inputs)}, dicts...)
+	}
+
+	runes := []rune(password)
+	return minimumEntropy(runes, matches(runes, dicts))
+}
+
+// minimumEntropy finds the sequence of matches that is the easiest to guess.
+func minimumEntropy(password []rune, matches []*Match) *Result {
+	bruteforce := math.Log2(cardinality(password))
+
+	// upTo holds the lowest entropy of the password up to every character,
+	// with the match that ends there or nil when the character is brute forced.
+	upTo := make([]float64, len(password))
+	last := make([]*Match, len(password))
+	get := func(k int) float64 {
+		if k < 0 {
+			return 0
+		}
+		return upTo[k]
+	}
+
+	for k := range password {
+		upTo[k] = get(k-1) + bruteforce
+		for _, m := range matches {
+			if m.J != k {
+				continue
+			}
+
+			if entropy := get(m.I-1) + m.Entropy; entropy < upTo[k] {
+				upTo[k] = entropy
+				last[k] = m
+			}
+		}
+	}
+
+	// Walk back along the matches, and fill the gaps with brute forced matches.
+	sequence := []*Match{}
+	end := len(password) - 1
+	addBruteforce := func(i, j int) {
+		if i > j {
+			return
+		}
+		sequence = append(sequence, &Match{
+			Pattern: "bruteforce",
+			I:       i,
+			J:       j,
+			Token:   string(password[i : j+1]),
+			Entropy: float64(j-i+1) * bruteforce,
+		})
+	}
+
+	for k := len(password) - 1; k >= 0; {
+		m := last[k]
+		if m == nil {
+			k--
+			continue
+		}
+
+		addBruteforce(m.J+1, end)
+		sequence = append(sequence, m)
+		end = m.I - 1
+		k = m.I - 1
+	}
+	addBruteforce(0, end)
+
+	for i, j := 0, len(sequence)-1; i < j; i, j = i+1, j-1 {
+		sequence[i], sequence[j] = sequence[j], sequence[i]
+	}
+
+	entropy := get(len(password) - 1)
+	crackTime := crackTime(entropy)
+
+	return &Result{
+		Entropy:   entropy,
+		CrackTime: crackTime,
+		Score:     score(crackTime),
+		Matches:   sequence,
+	}
+}
+
+// crackTime is the average time to guess a password with the entropy, half of all guesses.
+func crackTime(entropy float64) time.Duration {
+	seconds := 0.5 * math.Pow(2, entropy) * guessTime.Seconds()
+	if seconds >= math.MaxInt64/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// score rates the crack time from 0 to 4, like zxcvbn does.
+func score(t time.Duration) int {
+	seconds := t.Seconds()
+	switch {
+	case seconds < 1e2:
+		return 0
+	case seconds < 1e4:
+		return 1
+	case seconds < 1e6:
+		return 2
+	case seconds < 1e8:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// uppercaseEntropy is the extra entropy of the capitals in a word.
+// Capitalizing the first or last letter or all letters is common, so it only adds a bit.
+func uppercaseEntropy(word string) float64 {
+	upper, lower := 0, 0
+	for _, c := range word {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 0
+	}
+
+	runes := []rune(word)
+	first := unicode.IsUpper(runes[0]) && upper == 1
+	final := unicode.IsUpper(runes[len(runes)-1]) && upper == 1
+	if first || final || lower == 0 {
+		return 1
+	}
+
+	possibilities := 0.0
+	for i := 0; i <= min(upper, lower); i++ {
+		possibilities += binomial(upper+lower, i)
+	}
+	return math.Log2(possibilities)
+}
+
+// l33tEntropy is the extra entropy of the l33t substitutions in a token.
+func l33tEntropy(token []rune, subs map[rune]rune) float64 {
+	possibilities := 0.0
+	for sub, letter := range subs {
+		subbed, unsubbed := 0, 0
+		for _, c := range token {
+			switch unicode.ToLower(c) {
+			case sub:
+				subbed++
+			case letter:
+				unsubbed++
+			}
+		}
+
+		for i := 0; i <= min(subbed, unsubbed); i++ {
+			possibilities += binomial(subbed+unsubbed, i)
+		}
+	}
+
+	// A single substitution, like 4pple, still adds a bit.
+	return math.Max(1, math.Log2(possibilities))
+}
+
+// spatialEntropy is the entropy of a pattern of length keys on the keyboard, with
+// the number of turns and shifted keys.
+func spatialEntropy(g *graph, length int, turns int, shifted int) float64 {
+	possibilities := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(turns, i-1); j++ {
+			possibilities += binomial(i-1, j-1) * g.starts * math.Pow(g.degree, float64(j))
+		}
+	}
+	entropy := math.Log2(possibilities)
+
+	if shifted > 0 {
+		unshifted := length - shifted
+		possibilities := 0.0
+		for i := 0; i <= min(shifted, unshifted); i++ {
+			possibilities += binomial(shifted+unshifted, i)
+		}
+		entropy += math.Log2(possibilities)
+	}
+
+	return entropy
+}
+
+// sequenceEntropy is the entropy of a sequence, like abcd or 9876.
+func sequenceEntropy(token []rune, ascending bool) float64 {
+	var base float64
+	switch first := token[0]; {
+	case first == 'a' || first == '1':
+		base = 1
+	case isDigit(first):
+		base = math.Log2(10)
+	case unicode.IsLower(first):
+		base = math.Log2(26)
+	default:
+		base = math.Log2(26) + 1
+	}
+
+	if !ascending {
+		base++
+	}
+
+	return base + math.Log2(float64(len(token)))
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+
+	res := 1.0
+	for i := 1; i <= k; i++ {
+		res *= float64(n - k + i)
+		res /= float64(i)
+	}
+	return res
+}
+
+func isSeparator(c rune) bool {
+	return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+}
@@ -0,0 +1,189 @@
+package strength
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		password string
+		words    []string
+
+		// The pattern and the token of every match, in order.
+		matches [][2]string
+
+		// The range of expected scores.
+		min, max int
+	}{
+		// Dictionary words.
+		{password: "password", matches: [][2]string{{"passwords", "password"}}, min: 0, max: 0},
+		{password: "Elephant", matches: [][2]string{{"passwords", "Elephant"}}, min: 0, max: 0},
+		{password: "Rosalind", matches: [][2]string{{"female names", "Rosalind"}}, min: 0, max: 0},
+		{password: "example", matches: [][2]string{{"english", "example"}}, min: 0, max: 0},
+		{password: "tnahpele", matches: [][2]string{{"passwords", "tnahpele"}}, min: 0, max: 0},
+		{
+			password: "correcthorsebatterystaple",
+			matches: [][2]string{
+				{"english", "correct"},
+				{"passwords", "horse"},
+				{"english", "battery"},
+				{"english", "staple"},
+			},
+			min: 3, max: 4,
+		},
+
+		// L33t.
+		{password: "p@ssw0rd", matches: [][2]string{{"passwords", "p@ssw0rd"}}, min: 0, max: 0},
+		{password: "3l3ph4nt", matches: [][2]string{{"passwords", "3l3ph4nt"}}, min: 0, max: 0},
+
+		// Sequences.
+		{password: "abcdef", matches: [][2]string{{"sequence", "abcdef"}}, min: 0, max: 0},
+		{password: "mnopqrst", matches: [][2]string{{"sequence", "mnopqrst"}}, min: 0, max: 0},
+		{password: "zyxwvu", matches: [][2]string{{"sequence", "zyxwvu"}}, min: 0, max: 0},
+		{password: "987654", matches: [][2]string{{"sequence", "987654"}}, min: 0, max: 0},
+
+		// Repeats.
+		{password: "zzzzzzzzzzzz", matches: [][2]string{{"repeat", "zzzzzzzzzzzz"}}, min: 0, max: 0},
+
+		// Keyboard patterns.
+		{password: "asdfghjkl;", matches: [][2]string{{"qwerty", "asdfghjkl;"}}, min: 0, max: 0},
+		{password: "mju7nhy6", matches: [][2]string{{"qwerty", "mju7"}, {"qwerty", "nhy6"}}, min: 0, max: 0},
+
+		// Dates and years.
+		{password: "07/11/1987", matches: [][2]string{{"date", "07/11/1987"}}, min: 0, max: 0},
+		{password: "1987-11-07", matches: [][2]string{{"date", "1987-11-07"}}, min: 0, max: 0},
+		{password: "elephant1987", matches: [][2]string{{"passwords", "elephant"}, {"year", "1987"}}, min: 0, max: 0},
+
+		// The remaining characters are brute forced.
+		{password: "elephant!", matches: [][2]string{{"passwords", "elephant"}, {"bruteforce", "!"}}, min: 0, max: 1},
+		{password: "kX9#mQ2$vL8@", matches: [][2]string{{"bruteforce", "kX9#mQ2$vL8@"}}, min: 4, max: 4},
+
+		// Words the password should not be based on.
+		{password: "example2024", words: []string{"web/example"}, matches: [][2]string{{"user inputs", "example"}, {"year", "2024"}}, min: 0, max: 0},
+
+		{password: "", matches: [][2]string{}, min: 0, max: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.password, func(t *testing.T) {
+			result := Estimate(test.password, test.words...)
+
+			matches := [][2]string{}
+			for _, m := range result.Matches {
+				name := m.Pattern
+				if m.Dictionary != "" {
+					name = m.Dictionary
+				}
+				matches = append(matches, [2]string{name, m.Token})
+			}
+
+			if !reflect.DeepEqual(matches, test.matches) {
+				t.Errorf("matches %v, want %v", matches, test.matches)
+			}
+
+			if result.Score < test.min || result.Score > test.max {
+				t.Errorf("score %d, want %d to %d (%.1f bits)", result.Score, test.min, test.max, result.Entropy)
+			}
+
+			if result.Weak() != (result.Score < 3) {
+				t.Errorf("weak is %v for score %d", result.Weak(), result.Score)
+			}
+		})
+	}
+}
+
+func TestEstimateMatchesCoverPassword(t *testing.T) {
+	for _, password := range []string{"Tr0ub4dor&3", "monkey123!", "g7Hq2#pLz9!wR4vT", "1qaz2wsx", "ab€cd"} {
+		result := Estimate(password)
+
+		next := 0
+		token := ""
+		entropy := 0.0
+		for _, m := range result.Matches {
+			if m.I != next || m.J < m.I {
+				t.Errorf("%q: match %q at %d to %d, expected it to start at %d", password, m.Token, m.I, m.J, next)
+			}
+			next = m.J + 1
+			token += m.Token
+			entropy += m.Entropy
+		}
+
+		if token != password {
+			t.Errorf("%q: matches cover %q", password, token)
+		}
+		if diff := entropy - result.Entropy; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%q: matches have %.2f bits, result has %.2f", password, entropy, result.Entropy)
+		}
+	}
+}
+
+func TestEstimateRepeatedWords(t *testing.T) {
+	// Every common word takes at least a guess, so repeating one adds entropy.
+	once := Estimate("password")
+	twice := Estimate("passwordpassword")
+	thrice := Estimate("passwordpasswordpassword")
+
+	if once.Entropy < 1 {
+		t.Errorf("expected at least 1 bit for a single word, got %.1f", once.Entropy)
+	}
+	if twice.Entropy <= once.Entropy || thrice.Entropy <= twice.Entropy {
+		t.Errorf("expected repeated words to add entropy, got %.1f, %.1f and %.1f bits", once.Entropy, twice.Entropy, thrice.Entropy)
+	}
+	if twice.CrackTime <= 0 {
+		t.Errorf("expected a crack time, got %s", twice.CrackTime)
+	}
+	if twice.Score != 0 {
+		t.Errorf("expected a repeated common word to be weak, got a score of %d", twice.Score)
+	}
+	for _, m := range twice.Matches {
+		if m.Entropy < 1 {
+			t.Errorf("match %q has %.1f bits, expected at least 1", m.Token, m.Entropy)
+		}
+	}
+}
+
+func TestEstimateOrdering(t *testing.T) {
+	// Longer random passwords are always harder to guess.
+	prev := 0.0
+	for _, password := range []string{"kX9#", "kX9#mQ2$", "kX9#mQ2$vL8@", "kX9#mQ2$vL8@pW5&"} {
+		entropy := Estimate(password).Entropy
+		if entropy <= prev {
+			t.Errorf("%q has %.1f bits, expected more than %.1f", password, entropy, prev)
+		}
+		prev = entropy
+	}
+}
+
+func TestCrackTimeDisplay(t *testing.T) {
+	tests := []struct {
+		time time.Duration
+		want string
+	}{
+		{0, "instant"},
+		{500 * time.Millisecond, "instant"},
+		{time.Second, "1 second"},
+		{59 * time.Second, "59 seconds"},
+		{90 * time.Minute, "1 hour"},
+		{3 * 24 * time.Hour, "3 days"},
+		{40 * 24 * time.Hour, "1 month"},
+		{2 * 365 * 24 * time.Hour, "2 years"},
+		{200 * 365 * 24 * time.Hour, "centuries"},
+	}
+
+	for _, test := range tests {
+		result := &Result{CrackTime: test.time}
+		if got := result.CrackTimeDisplay(); got != test.want {
+			t.Errorf("CrackTimeDisplay(%s) = %q, want %q", test.time, got, test.want)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	result := Estimate("elephant1987!elephant")
+	want := []string{"passwords", "year"}
+	if got := result.Patterns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Patterns() = %v, want %v", got, want)
+	}
+}